@@ -0,0 +1,33 @@
+package astar
+
+// Costs is a terrain cost model, the cost of moving onto x, y of a game map
+// holding cell: 0 empty, 1 barrier, 2 forest. A negative cost blocks the
+// cell. Path estimates the cost left with the Manhattan distance, so costs
+// below 1 may give paths which are not the shortest.
+type Costs func(x, y int, cell int32) float64
+
+// CellCosts returns the cost model costing each cell type what costs maps
+// it to, the types left out block their cells.
+func CellCosts(costs map[int32]float64) Costs {
+	return func(x, y int, cell int32) float64 {
+		if cost, ok := costs[cell]; ok {
+			return cost
+		}
+		return -1
+	}
+}
+
+var (
+	// DefaultCosts costs a forest cell as KindGrass, the model of InitWorld
+	// and InitGrid. Paths keep off forests where they can.
+	DefaultCosts = CellCosts(map[int32]float64{
+		0: KindCosts[KindPlain],
+		2: KindCosts[KindGrass],
+	})
+	// SprintCosts costs a forest cell as a plain one. Forests only hide
+	// tanks, they move through them as fast as anywhere.
+	SprintCosts = CellCosts(map[int32]float64{0: 1, 2: 1})
+	// StealthCosts costs a plain cell twice a forest cell, paths go through
+	// forests, out of sight, where they can.
+	StealthCosts = CellCosts(map[int32]float64{0: 2, 2: 1})
+)
@@ -0,0 +1,265 @@
+package astar
+
+// Grid is a four-connected grid for A* without the Pather interface. Cells
+// are held in flat slices indexed by y*Width+x, and a grid keeps its nodes
+// between searches: once built, a search does not allocate. Path on the
+// Tiles of a World finds the same paths, the grid is the fast way for a
+// board searched many times.
+//
+// A Grid is not safe for concurrent searches.
+type Grid struct {
+	Width, Height int
+	// costs[y*Width+x] is the cost of moving onto x, y, a negative cost
+	// blocks the cell.
+	costs []float64
+
+	nodes []gridNode
+	open  gridQueue
+	// search is the number of the current search, a node of another search
+	// is as new.
+	search uint32
+	path   []int
+}
+
+// gridNode stores the A* data of a cell, see node.
+type gridNode struct {
+	cost   float64
+	rank   float64
+	parent int
+	open   bool
+	closed bool
+	index  int
+	search uint32
+}
+
+// gridOffsets are the neighbors of a cell, in the order of Tile.
+var gridOffsets = [4][2]int{
+	{-1, 0},
+	{1, 0},
+	{0, -1},
+	{0, 1},
+}
+
+// NewGrid returns a grid of plain cells, with a cost of 1.
+func NewGrid(width, height int) *Grid {
+	g := &Grid{
+		Width:  width,
+		Height: height,
+		costs:  make([]float64, width*height),
+		nodes:  make([]gridNode, width*height),
+		open:   gridQueue{index: make([]int, 0, width*height)},
+	}
+	for i := range g.costs {
+		g.costs[i] = KindCosts[KindPlain]
+	}
+	return g
+}
+
+// InitGrid returns the grid of gameMap, with the costs InitWorld gives its
+// tiles.
+func InitGrid(gameMap [50][50]int32) *Grid {
+	return InitGridCosts(gameMap, DefaultCosts)
+}
+
+// InitGridCosts returns the grid of gameMap with the costs of the model.
+func InitGridCosts(gameMap [50][50]int32, costs Costs) *Grid {
+	g := NewGrid(len(gameMap), len(gameMap[0]))
+	for i := 0; i < len(gameMap); i++ {
+		for j := 0; j < len(gameMap[i]); j++ {
+			g.SetCost(i, j, costs(i, j, gameMap[i][j]))
+		}
+	}
+	return g
+}
+
+// Index returns the index of x, y in the grid.
+func (g *Grid) Index(x, y int) int {
+	return y*g.Width + x
+}
+
+// XY returns the coordinates of the cell at index i.
+func (g *Grid) XY(i int) (x, y int) {
+	return i % g.Width, i / g.Width
+}
+
+// Cost returns the cost of moving onto x, y, negative if it is blocked or
+// off the grid.
+func (g *Grid) Cost(x, y int) float64 {
+	if x < 0 || x >= g.Width || y < 0 || y >= g.Height {
+		return -1
+	}
+	return g.costs[g.Index(x, y)]
+}
+
+// SetCost sets the cost of moving onto x, y, a negative cost blocks it.
+func (g *Grid) SetCost(x, y int, cost float64) {
+	g.costs[g.Index(x, y)] = cost
+}
+
+// Path calculates a short path and the distance between two cells. The path
+// holds the indexes of the cells from the goal back to the start, it is only
+// valid until the next search of the grid.
+//
+// If no path is found, found will be false.
+func (g *Grid) Path(fromX, fromY, toX, toY int) (path []int, distance float64, found bool) {
+	g.search++
+	g.open.reset(g.nodes)
+	from, to := g.Index(fromX, fromY), g.Index(toX, toY)
+	g.open.push(g.node(from))
+	g.nodes[from].open = true
+	for {
+		if g.open.Len() == 0 {
+			// There's no path, return found false.
+			return
+		}
+		current := g.open.pop()
+		cn := &g.nodes[current]
+		cn.open = false
+		cn.closed = true
+
+		if current == to {
+			// Found a path to the goal.
+			g.path = g.path[:0]
+			for curr := current; curr >= 0; curr = g.nodes[curr].parent {
+				g.path = append(g.path, curr)
+			}
+			return g.path, cn.cost, true
+		}
+
+		x, y := g.XY(current)
+		for _, offset := range gridOffsets {
+			nx, ny := x+offset[0], y+offset[1]
+			step := g.Cost(nx, ny)
+			if step < 0 {
+				continue
+			}
+			neighbor := g.node(g.Index(nx, ny))
+			nn := &g.nodes[neighbor]
+			cost := cn.cost + step
+			if cost < nn.cost {
+				if nn.open {
+					g.open.remove(nn.index)
+				}
+				nn.open = false
+				nn.closed = false
+			}
+			if !nn.open && !nn.closed {
+				nn.cost = cost
+				nn.open = true
+				nn.rank = cost + manhattan(nx, ny, toX, toY)
+				nn.parent = current
+				g.open.push(neighbor)
+			}
+		}
+	}
+}
+
+// node returns the node of cell i, cleared if an earlier search left it.
+func (g *Grid) node(i int) int {
+	if g.nodes[i].search != g.search {
+		g.nodes[i] = gridNode{parent: -1, index: -1, search: g.search}
+	}
+	return i
+}
+
+func manhattan(x, y, toX, toY int) float64 {
+	absX := toX - x
+	if absX < 0 {
+		absX = -absX
+	}
+	absY := toY - y
+	if absY < 0 {
+		absY = -absY
+	}
+	return float64(absX + absY)
+}
+
+// gridQueue is a binary heap of the open cells by rank. It sifts the way
+// container/heap does, so that ties come out in the order of Path, without
+// boxing the cells in interfaces.
+type gridQueue struct {
+	nodes []gridNode
+	index []int
+}
+
+func (q *gridQueue) reset(nodes []gridNode) {
+	q.nodes = nodes
+	q.index = q.index[:0]
+}
+
+func (q *gridQueue) Len() int {
+	return len(q.index)
+}
+
+func (q *gridQueue) less(i, j int) bool {
+	return q.nodes[q.index[i]].rank < q.nodes[q.index[j]].rank
+}
+
+func (q *gridQueue) swap(i, j int) {
+	q.index[i], q.index[j] = q.index[j], q.index[i]
+	q.nodes[q.index[i]].index = i
+	q.nodes[q.index[j]].index = j
+}
+
+func (q *gridQueue) push(cell int) {
+	q.nodes[cell].index = len(q.index)
+	q.index = append(q.index, cell)
+	q.up(len(q.index) - 1)
+}
+
+func (q *gridQueue) pop() int {
+	n := len(q.index) - 1
+	q.swap(0, n)
+	q.down(0, n)
+	return q.removeLast()
+}
+
+func (q *gridQueue) remove(i int) {
+	n := len(q.index) - 1
+	if n != i {
+		q.swap(i, n)
+		if !q.down(i, n) {
+			q.up(i)
+		}
+	}
+	q.removeLast()
+}
+
+func (q *gridQueue) removeLast() int {
+	n := len(q.index) - 1
+	cell := q.index[n]
+	q.nodes[cell].index = -1
+	q.index = q.index[:n]
+	return cell
+}
+
+func (q *gridQueue) up(j int) {
+	for {
+		i := (j - 1) / 2 // parent
+		if i == j || !q.less(j, i) {
+			break
+		}
+		q.swap(i, j)
+		j = i
+	}
+}
+
+func (q *gridQueue) down(i0, n int) bool {
+	i := i0
+	for {
+		j1 := 2*i + 1
+		if j1 >= n || j1 < 0 { // j1 < 0 after int overflow
+			break
+		}
+		j := j1 // left child
+		if j2 := j1 + 1; j2 < n && q.less(j2, j1) {
+			j = j2 // = 2*i + 2  // right child
+		}
+		if !q.less(j, i) {
+			break
+		}
+		q.swap(i, j)
+		i = j
+	}
+	return i > i0
+}
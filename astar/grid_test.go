@@ -0,0 +1,137 @@
+package astar
+
+import (
+	"gamemap"
+	"path/filepath"
+	"testing"
+)
+
+// mapFiles are the maps of the competition.
+const mapFiles = "../game_engine/maps/*.txt"
+
+// loadCells returns the map in the file as the bots give it to A*, the cells
+// off the board being barriers.
+func loadCells(tb testing.TB, path string) (cells [50][50]int32, size int) {
+	m, err := gamemap.LoadFile(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	for x := range cells {
+		for y := range cells[x] {
+			cells[x][y] = 1
+			if x < len(m) && y < len(m[x]) {
+				cells[x][y] = m[x][y]
+			}
+		}
+	}
+	return cells, len(m)
+}
+
+// gridPath searches the way Path on a World does, with the goal costing
+// what a KindTo tile costs.
+func gridPath(g *Grid, fromX, fromY, toX, toY int) ([]int, float64, bool) {
+	cost := g.Cost(toX, toY)
+	g.SetCost(toX, toY, KindCosts[KindTo])
+	defer g.SetCost(toX, toY, cost)
+	return g.Path(fromX, fromY, toX, toY)
+}
+
+func TestGridPath(t *testing.T) {
+	files, err := filepath.Glob(mapFiles)
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no maps in %s: %v", mapFiles, err)
+	}
+	for _, file := range files {
+		cells, size := loadCells(t, file)
+		g := InitGrid(cells)
+		c := size / 2
+		for x := 0; x < size; x++ {
+			for y := 0; y < size; y++ {
+				if cells[x][y] == 1 || x == c && y == c {
+					continue
+				}
+				w := InitWorld(cells)
+				_, want, wantFound := Path(w.Start(x, y), w.End(c, c))
+				path, got, found := gridPath(g, x, y, c, c)
+				if found != wantFound || got != want {
+					t.Fatalf("%s from %d,%d: grid %v %v, world %v %v", file, x, y, got, found, want, wantFound)
+				}
+				if !found {
+					continue
+				}
+				// the path goes from the goal back to the start, a step at a time
+				if path[0] != g.Index(c, c) || path[len(path)-1] != g.Index(x, y) {
+					t.Fatalf("%s from %d,%d: path %v does not join the cells", file, x, y, path)
+				}
+				for i := 1; i < len(path); i++ {
+					ax, ay := g.XY(path[i-1])
+					bx, by := g.XY(path[i])
+					if manhattan(ax, ay, bx, by) != 1 {
+						t.Fatalf("%s from %d,%d: path %v jumps", file, x, y, path)
+					}
+				}
+			}
+		}
+	}
+}
+
+// fourTanks are the cells of the four tanks of a side when they spawn.
+var fourTanks = [4][2]int{{1, 1}, {1, 2}, {2, 1}, {2, 2}}
+
+// BenchmarkWorldPath routes four tanks to the flag, building a World for
+// each as the bots did.
+func BenchmarkWorldPath(b *testing.B) {
+	cells, size := loadCells(b, "../game_engine/maps/secondweekmap.txt")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, tank := range fourTanks {
+			w := InitWorld(cells)
+			if _, _, found := Path(w.Start(tank[0], tank[1]), w.End(size/2, size/2)); !found {
+				b.Fatal("no path")
+			}
+		}
+	}
+}
+
+// BenchmarkGridPath routes four tanks to the flag on one Grid a round.
+func BenchmarkGridPath(b *testing.B) {
+	cells, size := loadCells(b, "../game_engine/maps/secondweekmap.txt")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		g := InitGrid(cells)
+		for _, tank := range fourTanks {
+			if _, _, found := gridPath(g, tank[0], tank[1], size/2, size/2); !found {
+				b.Fatal("no path")
+			}
+		}
+	}
+}
+
+func TestGridCosts(t *testing.T) {
+	models := map[string]Costs{
+		"default": DefaultCosts,
+		"sprint":  SprintCosts,
+		"stealth": StealthCosts,
+		// a cell costing 0 is not one left to the kind
+		"free forest": CellCosts(map[int32]float64{0: 1, 2: 0}),
+	}
+	// the map with the most forest
+	cells, size := loadCells(t, "../game_engine/maps/firstweekmap.txt")
+	c := size / 2
+	for name, costs := range models {
+		g := InitGridCosts(cells, costs)
+		for x := 0; x < size; x++ {
+			for y := 0; y < size; y++ {
+				if cells[x][y] == 1 || x == c && y == c {
+					continue
+				}
+				w := InitWorldCosts(cells, costs)
+				_, want, wantFound := Path(w.Start(x, y), w.End(c, c))
+				_, got, found := gridPath(g, x, y, c, c)
+				if found != wantFound || got != want {
+					t.Fatalf("%s from %d,%d: grid %v %v, world %v %v", name, x, y, got, found, want, wantFound)
+				}
+			}
+		}
+	}
+}
@@ -62,6 +62,10 @@ var KindCosts = map[int]float64{
 type Tile struct {
 	// Kind is the kind of tile, potentially affecting movement.
 	Kind int
+	// Cost is the cost of moving onto the tile if HasCost, else the tile
+	// costs KindCosts of Kind.
+	Cost    float64
+	HasCost bool
 	// X and Y are the coordinates of the tile.
 	X, Y int
 	// W is a reference to the World that the tile is a part of.
@@ -89,6 +93,9 @@ func (t *Tile) PathNeighbors() []Pather {
 // PathNeighborCost returns the movement cost of the directly neighboring tile.
 func (t *Tile) PathNeighborCost(to Pather) float64 {
 	toT := to.(*Tile)
+	if toT.HasCost {
+		return toT.Cost
+	}
 	return KindCosts[toT.Kind]
 }
 
@@ -215,21 +222,29 @@ func ParseWorld(input string) World {
 
 // InitWorld by gameMap
 func InitWorld(gameMap [50][50]int32) World {
+	return InitWorldCosts(gameMap, DefaultCosts)
+}
+
+// InitWorldCosts returns the world of gameMap with the costs of the model.
+// Forest tiles are of KindGrass, the other open tiles of KindPlain, and
+// tiles the model blocks of KindBlocker.
+func InitWorldCosts(gameMap [50][50]int32, costs Costs) World {
 	w := World{}
-	var kind int
 	for i := 0; i < len(gameMap); i++ {
 		for j := 0; j < len(gameMap[i]); j++ {
-			if gameMap[i][j] == 0 {
-				kind = KindPlain
-			} else if gameMap[i][j] == 1 {
-				kind = KindBlocker
-			} else if gameMap[i][j] == 2 {
+			cost := costs(i, j, gameMap[i][j])
+			if cost < 0 {
+				w.SetTile(&Tile{Kind: KindBlocker}, i, j)
+				continue
+			}
+			kind := KindPlain
+			if gameMap[i][j] == 2 {
 				kind = KindGrass
-			} else {
-				kind = KindBlocker
 			}
 			w.SetTile(&Tile{
-				Kind: kind,
+				Kind:    kind,
+				Cost:    cost,
+				HasCost: true,
 			}, i, j)
 		}
 	}
@@ -0,0 +1,64 @@
+// Package bot holds the decision making of the tank bots. A Strategy looks
+// at a World, the snapshot of a match at one round, and returns the orders
+// of my tanks. Strategies register under a name so that a server can pick
+// one at startup.
+//
+// The package also holds the parts strategies are built from, the movement
+// planner Mover, the fire planner Fire and the GrassScanner, and the Session
+// serving a strategy over thrift.
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+// Default is the name of the strategy used when none is chosen.
+const Default = "default"
+
+// Strategy decides the orders of my tanks. A strategy is created for every
+// match and may keep its own state from one round to the next.
+type Strategy interface {
+	Orders(w *World) []*player.Order
+}
+
+// ContextStrategy is a Strategy which can stop early. A session calls
+// OrdersContext instead of Orders, with a context done at the deadline of
+// the round. It must then return soon, with the best orders found so far.
+type ContextStrategy interface {
+	Strategy
+	OrdersContext(ctx context.Context, w *World) []*player.Order
+}
+
+var strategies = map[string]func() Strategy{}
+
+// Register makes a strategy available by name. It panics if the name is
+// taken, registering is meant for init functions.
+func Register(name string, newStrategy func() Strategy) {
+	if _, ok := strategies[name]; ok {
+		panic("bot: strategy registered twice: " + name)
+	}
+	strategies[name] = newStrategy
+}
+
+// New returns a new instance of the named strategy.
+func New(name string) (Strategy, error) {
+	newStrategy, ok := strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q, the strategies are %v", name, Names())
+	}
+	return newStrategy(), nil
+}
+
+// Names returns the registered strategies in alphabetical order.
+func Names() []string {
+	names := []string{}
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,60 @@
+package bot
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// Config is what the servers of the bots differ in.
+type Config struct {
+	// Addr is the address the server listens on.
+	Addr string
+	// Transport is the thrift transport, buffered or framed, or auto to
+	// take the one of each caller.
+	Transport string
+	// Protocol is the thrift protocol, binary, compact or json.
+	Protocol string
+	// Strategy is the name of the Strategy which gives the orders.
+	Strategy string
+	// Name is the name the replays give me, Serve sets it to Addr when it
+	// is empty.
+	Name string
+	// LogLevel is debug, info, warn or error.
+	LogLevel string
+	// ReplayDir is where every match is recorded, recording is off when it
+	// is empty. Recording is best effort, its errors are ignored so that
+	// they never cost a round.
+	ReplayDir string
+}
+
+// DefaultConfig returns the config of the bot in the Docker image, which
+// start.sh runs without arguments: port 80 and what GameEngine speaks.
+func DefaultConfig() Config {
+	return Config{
+		Addr:      "0.0.0.0:80",
+		Transport: "buffered",
+		Protocol:  "binary",
+		Strategy:  Default,
+		LogLevel:  "info",
+	}
+}
+
+// Flags defines a flag for every field of the config on fs. The values in c
+// are the defaults, overridden by the environment variable of the field if
+// it is set.
+func (c *Config) Flags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Addr, "addr", env("BOT_ADDR", c.Addr), "listen address ($BOT_ADDR)")
+	fs.StringVar(&c.Transport, "transport", env("BOT_TRANSPORT", c.Transport), "thrift transport, buffered, framed or auto to follow the caller ($BOT_TRANSPORT)")
+	fs.StringVar(&c.Protocol, "protocol", env("BOT_PROTOCOL", c.Protocol), "thrift protocol, binary, compact or json ($BOT_PROTOCOL)")
+	fs.StringVar(&c.Strategy, "strategy", env("BOT_STRATEGY", c.Strategy), fmt.Sprintf("strategy, one of %v ($BOT_STRATEGY)", Names()))
+	fs.StringVar(&c.LogLevel, "log", env("BOT_LOG_LEVEL", c.LogLevel), "log level, debug, info, warn or error ($BOT_LOG_LEVEL)")
+	fs.StringVar(&c.ReplayDir, "replay", env("REPLAY_DIR", c.ReplayDir), "directory to record every match in, empty to not record ($REPLAY_DIR)")
+}
+
+func env(name, value string) string {
+	if v, ok := os.LookupEnv(name); ok {
+		return v
+	}
+	return value
+}
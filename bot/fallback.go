@@ -0,0 +1,69 @@
+package bot
+
+import "github.com/eleme/purchaseMeiTuan/player"
+
+// Fallback returns the orders sent when the strategy is late. It is cheap
+// and safe: a tank a shell will hit within two rounds steps out of its line,
+// the other tanks stay where they are.
+func Fallback(w *World) []*player.Order {
+	orders := []*player.Order{}
+	shots := PredictShots(w)
+	for _, tank := range w.MyTanks() {
+		if threats := shots.Threats(tank.Pos, 2); len(threats) > 0 {
+			orders = append(orders, sidestep(w, tank, threats[0].Dir))
+		}
+	}
+	return orders
+}
+
+// sidestep returns the order taking the tank out of the line of a shell
+// flying in dir. Tanks move the way they face, a tank facing along the line
+// first turns to a free side.
+func sidestep(w *World, tank *player.Tank, dir player.Direction) *player.Order {
+	sides := []player.Direction{player.Direction_LEFT, player.Direction_RIGHT}
+	if dir == player.Direction_LEFT || dir == player.Direction_RIGHT {
+		sides = []player.Direction{player.Direction_UP, player.Direction_DOWN}
+	}
+	for _, side := range sides {
+		if side == tank.Dir && free(w, tank.Pos, side) {
+			return &player.Order{TankId: tank.ID, Order: "move", Dir: side}
+		}
+	}
+	for _, side := range sides {
+		if free(w, tank.Pos, side) {
+			return &player.Order{TankId: tank.ID, Order: "turnTo", Dir: side}
+		}
+	}
+	return &player.Order{TankId: tank.ID, Order: "turnTo", Dir: sides[0]}
+}
+
+// free reports whether the cell next to pos in dir is neither a barrier nor
+// taken by a tank.
+func free(w *World, pos *player.Position, dir player.Direction) bool {
+	next := step(pos, dir)
+	if w.Cell(int(next.X), int(next.Y)) == 1 {
+		return false
+	}
+	for _, t := range w.State.Tanks {
+		if t.Pos.X == next.X && t.Pos.Y == next.Y {
+			return false
+		}
+	}
+	return true
+}
+
+// step returns the cell next to pos in dir. X is the row, UP goes to the
+// row above.
+func step(pos *player.Position, dir player.Direction) *player.Position {
+	switch dir {
+	case player.Direction_UP:
+		return &player.Position{X: pos.X - 1, Y: pos.Y}
+	case player.Direction_DOWN:
+		return &player.Position{X: pos.X + 1, Y: pos.Y}
+	case player.Direction_LEFT:
+		return &player.Position{X: pos.X, Y: pos.Y - 1}
+	case player.Direction_RIGHT:
+		return &player.Position{X: pos.X, Y: pos.Y + 1}
+	}
+	return pos
+}
@@ -0,0 +1,313 @@
+package bot
+
+import (
+	"astar"
+	"math"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+// unreachable is the distance of a cell with no way to the targets.
+const unreachable = -1
+
+// Fields are the distance fields of the cells tanks keep going to on a
+// map. The session computes them once a match, when the map comes.
+type Fields struct {
+	// Flag leads to the center, where the flag is.
+	Flag *Field
+	// Spawns lead to the corners the tanks spawn in, the top left one
+	// first.
+	Spawns [2]*Field
+	// Forests lead to the forests, a forest being forest cells next to
+	// each other, to the nearest cell of each.
+	Forests []*Field
+}
+
+// NewFields computes the fields of the map.
+func NewFields(gamemap [][]int32) *Fields {
+	size := len(gamemap)
+	fs := &Fields{
+		Flag: NewField(gamemap, &player.Position{X: int32(size / 2), Y: int32(size / 2)}),
+		Spawns: [2]*Field{
+			NewField(gamemap, &player.Position{X: 1, Y: 1}),
+			NewField(gamemap, &player.Position{X: int32(size - 2), Y: int32(size - 2)}),
+		},
+	}
+	for _, forest := range forests(gamemap) {
+		fs.Forests = append(fs.Forests, NewField(gamemap, forest...))
+	}
+	return fs
+}
+
+// To returns the field leading to dest alone, nil if there is none.
+func (fs *Fields) To(dest *player.Position) *Field {
+	if fs == nil {
+		return nil
+	}
+	for _, f := range append([]*Field{fs.Flag}, fs.Spawns[:]...) {
+		if len(f.targets) == 1 && f.targets[0] == f.index(dest) {
+			return f
+		}
+	}
+	return nil
+}
+
+// forests returns the cells of every forest of the map.
+func forests(gamemap [][]int32) [][]*player.Position {
+	size := len(gamemap)
+	seen := make([]bool, size*size)
+	all := [][]*player.Position{}
+	for x := range gamemap {
+		for y := range gamemap[x] {
+			if gamemap[x][y] != 2 || seen[x*size+y] {
+				continue
+			}
+			seen[x*size+y] = true
+			forest := []*player.Position{{X: int32(x), Y: int32(y)}}
+			for i := 0; i < len(forest); i++ {
+				for _, dir := range directions {
+					next := step(forest[i], dir)
+					nx, ny := int(next.X), int(next.Y)
+					if nx >= 0 && nx < size && ny >= 0 && ny < size && gamemap[nx][ny] == 2 && !seen[nx*size+ny] {
+						seen[nx*size+ny] = true
+						forest = append(forest, next)
+					}
+				}
+			}
+			all = append(all, forest)
+		}
+	}
+	return all
+}
+
+// Field holds for every cell of a map the cost of the shortest way to its
+// nearest target, so that the way there is looked up instead of searched.
+// Moving onto a cell costs what it costs Mover, astar.DefaultCosts rounded
+// to a whole cost, and a target 1 whatever is on it, the way the goal of a
+// path does. A Field never changes, Without makes a new one.
+type Field struct {
+	size    int
+	targets []int
+	// target[x*size+y] is whether x, y is a target.
+	target []bool
+	// costs[x*size+y] is the cost of moving onto x, y, negative if it is
+	// blocked.
+	costs []int
+	// dist[x*size+y] is the cost from x, y to the nearest target.
+	dist []int
+}
+
+// NewField computes the field of the targets on the map.
+func NewField(gamemap [][]int32, targets ...*player.Position) *Field {
+	size := len(gamemap)
+	f := &Field{
+		size:   size,
+		target: make([]bool, size*size),
+		costs:  make([]int, size*size),
+		dist:   make([]int, size*size),
+	}
+	for x := range gamemap {
+		for y := range gamemap[x] {
+			f.costs[x*size+y] = -1
+			if cost := astar.DefaultCosts(x, y, gamemap[x][y]); cost >= 0 {
+				f.costs[x*size+y] = int(math.Floor(cost + 0.5))
+			}
+		}
+	}
+	for i := range f.dist {
+		f.dist[i] = unreachable
+	}
+	q := &distQueue{}
+	for _, t := range targets {
+		if f.on(t) && !f.isTarget(f.index(t)) {
+			c := f.index(t)
+			f.targets = append(f.targets, c)
+			f.target[c] = true
+			f.costs[c] = int(astar.KindCosts[astar.KindTo])
+			f.dist[c] = 0
+			q.push(c, 0)
+		}
+	}
+	f.spread(q)
+	return f
+}
+
+// spread runs Dijkstra from the cells queued, lowering the distance of the
+// cells they lead to.
+func (f *Field) spread(q *distQueue) {
+	for {
+		c, d, ok := q.pop()
+		if !ok {
+			return
+		}
+		if d != f.dist[c] {
+			continue
+		}
+		for _, n := range f.neighbors(c) {
+			if n < 0 || f.costs[n] < 0 || f.isTarget(n) {
+				continue
+			}
+			if nd := d + f.costs[c]; f.dist[n] == unreachable || nd < f.dist[n] {
+				f.dist[n] = nd
+				q.push(n, nd)
+			}
+		}
+	}
+}
+
+// Without returns the field with the cells blocked, the targets excepted.
+// It only recomputes the cells whose way went through one of them.
+func (f *Field) Without(cells []*player.Position) *Field {
+	g := &Field{
+		size:    f.size,
+		targets: f.targets,
+		target:  f.target,
+		costs:   append([]int{}, f.costs...),
+		dist:    append([]int{}, f.dist...),
+	}
+	lost := []int{}
+	for _, pos := range cells {
+		if !g.on(pos) {
+			continue
+		}
+		c := g.index(pos)
+		if g.costs[c] < 0 || g.isTarget(c) {
+			continue
+		}
+		g.costs[c] = -1
+		if g.dist[c] != unreachable {
+			g.dist[c] = unreachable
+			lost = append(lost, c)
+		}
+	}
+	// a cell whose every way went through a lost cell is lost too
+	invalid := append([]int{}, lost...)
+	for i := 0; i < len(lost); i++ {
+		for _, n := range g.neighbors(lost[i]) {
+			if n >= 0 && g.dist[n] != unreachable && !g.isTarget(n) && !g.supported(n) {
+				g.dist[n] = unreachable
+				lost = append(lost, n)
+				invalid = append(invalid, n)
+			}
+		}
+	}
+	// the lost cells take the way of their neighbours left
+	q := &distQueue{}
+	for _, c := range invalid {
+		if g.costs[c] < 0 {
+			continue
+		}
+		for _, n := range g.neighbors(c) {
+			if n >= 0 && g.dist[n] != unreachable && g.costs[n] >= 0 {
+				if d := g.dist[n] + g.costs[n]; g.dist[c] == unreachable || d < g.dist[c] {
+					g.dist[c] = d
+				}
+			}
+		}
+		if g.dist[c] != unreachable {
+			q.push(c, g.dist[c])
+		}
+	}
+	g.spread(q)
+	return g
+}
+
+// supported reports whether a neighbour of c still gives c its distance.
+func (f *Field) supported(c int) bool {
+	for _, n := range f.neighbors(c) {
+		if n >= 0 && f.dist[n] != unreachable && f.costs[n] >= 0 && f.dist[n]+f.costs[n] == f.dist[c] {
+			return true
+		}
+	}
+	return false
+}
+
+// Distance returns the cost from pos to the nearest target, ok is false if
+// there is no way.
+func (f *Field) Distance(pos *player.Position) (distance int, ok bool) {
+	if !f.on(pos) || f.dist[f.index(pos)] == unreachable {
+		return 0, false
+	}
+	return f.dist[f.index(pos)], true
+}
+
+// Next returns the cell next to pos on the shortest way to the nearest
+// target, whatever is on pos. ok is false if pos is a target or there is
+// no way. The neighbours are tried in the order of astar.Grid.
+func (f *Field) Next(pos *player.Position) (next *player.Position, ok bool) {
+	if !f.on(pos) || f.isTarget(f.index(pos)) {
+		return nil, false
+	}
+	best := unreachable
+	for _, n := range f.neighbors(f.index(pos)) {
+		if n < 0 || f.dist[n] == unreachable || f.costs[n] < 0 {
+			continue
+		}
+		if d := f.dist[n] + f.costs[n]; best == unreachable || d < best {
+			best = d
+			next = &player.Position{X: int32(n / f.size), Y: int32(n % f.size)}
+		}
+	}
+	return next, next != nil
+}
+
+// isTarget reports whether c is a target, which its distance does not tell
+// once cells may cost 0.
+func (f *Field) isTarget(c int) bool {
+	return f.target[c]
+}
+
+// neighbors returns the cells next to c, in the order of astar.Grid. The
+// ones off the board are -1.
+func (f *Field) neighbors(c int) (cells [4]int) {
+	x, y := c/f.size, c%f.size
+	for i, d := range [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+		cells[i] = -1
+		if nx, ny := x+d[0], y+d[1]; nx >= 0 && nx < f.size && ny >= 0 && ny < f.size {
+			cells[i] = nx*f.size + ny
+		}
+	}
+	return cells
+}
+
+func (f *Field) on(pos *player.Position) bool {
+	return pos.X >= 0 && int(pos.X) < f.size && pos.Y >= 0 && int(pos.Y) < f.size
+}
+
+func (f *Field) index(pos *player.Position) int {
+	return int(pos.X)*f.size + int(pos.Y)
+}
+
+// distQueue is a queue of cells by distance. The costs being small, it
+// keeps a bucket of cells for every distance.
+type distQueue struct {
+	buckets [][]int
+	// at is the lowest distance with cells left.
+	at int
+	n  int
+}
+
+func (q *distQueue) push(c, d int) {
+	for len(q.buckets) <= d {
+		q.buckets = append(q.buckets, nil)
+	}
+	q.buckets[d] = append(q.buckets[d], c)
+	if d < q.at {
+		q.at = d
+	}
+	q.n++
+}
+
+func (q *distQueue) pop() (c, d int, ok bool) {
+	if q.n == 0 {
+		return 0, 0, false
+	}
+	for len(q.buckets[q.at]) == 0 {
+		q.at++
+	}
+	b := q.buckets[q.at]
+	c = b[len(b)-1]
+	q.buckets[q.at] = b[:len(b)-1]
+	q.n--
+	return c, q.at, true
+}
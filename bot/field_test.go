@@ -0,0 +1,71 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+func TestField(t *testing.T) {
+	pos := func(x, y int32) *player.Position { return &player.Position{X: x, Y: y} }
+	// a forest on 5,6 costs 2, the target 5,5 costs 1
+	m := openMap(10)
+	m[5][6] = 2
+	tests := []struct {
+		name    string
+		targets []*player.Position
+		without []*player.Position
+		from    *player.Position
+		// dist is the distance from from, -1 if there is no way, and next
+		// the cell after it.
+		dist int
+		next *player.Position
+	}{
+		{"straight", []*player.Position{pos(5, 5)}, nil, pos(2, 5), 3, pos(3, 5)},
+		{"through forest", []*player.Position{pos(5, 5)}, nil, pos(5, 8), 4, pos(5, 7)},
+		{"on target", []*player.Position{pos(5, 5)}, nil, pos(5, 5), 0, nil},
+		{"barrier", []*player.Position{pos(5, 5)}, nil, pos(0, 0), -1, nil},
+		{"nearest target", []*player.Position{pos(1, 1), pos(8, 8)}, nil, pos(7, 7), 2, pos(8, 7)},
+		{"without", []*player.Position{pos(5, 5)}, []*player.Position{pos(5, 7)}, pos(5, 8), 5, pos(4, 8)},
+		{"without target", []*player.Position{pos(5, 5)}, []*player.Position{pos(5, 5)}, pos(5, 7), 3, pos(5, 6)},
+		{"walled in", []*player.Position{pos(1, 1)}, []*player.Position{pos(1, 2), pos(2, 1)}, pos(5, 5), -1, nil},
+	}
+	for _, test := range tests {
+		f := NewField(m, test.targets...)
+		if test.without != nil {
+			f = f.Without(test.without)
+		}
+		dist, ok := f.Distance(test.from)
+		if !ok {
+			dist = -1
+		}
+		if dist != test.dist {
+			t.Errorf("%s: distance %d, want %d", test.name, dist, test.dist)
+		}
+		next, ok := f.Next(test.from)
+		if ok != (test.next != nil) || ok && *next != *test.next {
+			t.Errorf("%s: next %v %v, want %v", test.name, next, ok, test.next)
+		}
+	}
+}
+
+func TestFieldsTo(t *testing.T) {
+	fs := NewFields(openMap(10))
+	tests := []struct {
+		dest *player.Position
+		want *Field
+	}{
+		{&player.Position{X: 5, Y: 5}, fs.Flag},
+		{&player.Position{X: 1, Y: 1}, fs.Spawns[0]},
+		{&player.Position{X: 8, Y: 8}, fs.Spawns[1]},
+		{&player.Position{X: 4, Y: 4}, nil},
+	}
+	for _, test := range tests {
+		if got := fs.To(test.dest); got != test.want {
+			t.Errorf("To(%v) = %p, want %p", test.dest, got, test.want)
+		}
+	}
+	if (*Fields)(nil).To(&player.Position{X: 5, Y: 5}) != nil {
+		t.Error("no fields lead somewhere")
+	}
+}
@@ -0,0 +1,87 @@
+package bot
+
+import "github.com/eleme/purchaseMeiTuan/player"
+
+// fireDirs are the directions fired for the scores, in order.
+var fireDirs = [4]player.Direction{
+	player.Direction_UP,
+	player.Direction_DOWN,
+	player.Direction_LEFT,
+	player.Direction_RIGHT,
+}
+
+// Fire decides whether the tank at pos should fire, and in which direction.
+// target is a cell worth a shell even without a tank in sight, a forest cell
+// an enemy may hide in, it may be nil.
+//
+// 上下左右查找，每个方向打分：
+// 1. 若有敌方坦克，距离每增加一格减少 1，初始为半个地图宽；
+// 2. 若有己方坦克，距离每增加一格增加 1，初始为负的半个地图宽；
+// 3. 若有目标草丛，距离每增加一格减少 0.5，初始为四分之一地图宽。
+// 最高分方向的分数够高才开炮。
+//
+// A direction is scanned up to the first barrier, where the shell would
+// stop. The bot of the competition scanned UP and DOWN along Y and LEFT and
+// RIGHT along X, and only the cells whose X and Y were both under half the
+// width of the board.
+func Fire(w *World, pos *player.Position, target *player.Position) (player.Direction, bool) {
+	var scores [4]int
+	speedOffset := int(w.Args.ShellSpeed - w.Args.TankSpeed)
+	half := w.Size() / 2
+	x, y := int(pos.X), int(pos.Y)
+	enemies := w.Enemies()
+	friends := w.MyTanks()
+	for i := range scores {
+		for step := 1; step < half*speedOffset; step++ {
+			realX, realY := x, y
+			switch fireDirs[i] {
+			case player.Direction_UP:
+				realX = x - step
+			case player.Direction_DOWN:
+				realX = x + step
+			case player.Direction_LEFT:
+				realY = y - step
+			case player.Direction_RIGHT:
+				realY = y + step
+			}
+			// 碰到障碍或越界跳出循环
+			if w.Cell(realX, realY) == 1 {
+				break
+			}
+			if at(enemies, realX, realY) {
+				scores[i] += half - step/speedOffset
+			}
+			if at(friends, realX, realY) {
+				scores[i] += -half + step/speedOffset
+			}
+			if target != nil && target.X == int32(realX) && target.Y == int32(realY) {
+				scores[i] += half/2 - int(float32(step)/float32(speedOffset)*0.5)
+			}
+		}
+	}
+
+	// 取最高分方向开炮
+	index := 0
+	value := scores[0]
+	total := scores[0] + scores[1] + scores[2] + scores[3]
+	for i := 1; i < len(scores); i++ {
+		if scores[i] > value {
+			value = scores[i]
+			index = i
+		}
+	}
+	if (value >= total-value || value >= half-1) && value >= 6 {
+		return fireDirs[index], true
+	}
+	return 0, false
+}
+
+// at reports whether one of the tanks stands on x, y.
+func at(tanks []*player.Tank, x, y int) bool {
+	for _, t := range tanks {
+		if t.Pos.X == int32(x) && t.Pos.Y == int32(y) {
+			return true
+		}
+	}
+	return false
+}
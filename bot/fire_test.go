@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+func TestFire(t *testing.T) {
+	tank := func(id, x, y int32) *player.Tank {
+		return &player.Tank{ID: id, Pos: &player.Position{X: x, Y: y}, Dir: player.Direction_UP, Hp: 1}
+	}
+	pos := func(x, y int32) *player.Position { return &player.Position{X: x, Y: y} }
+	// on a board of 30 an enemy is worth 15 less a cell a step and a target
+	// half of that; a barrier stands on 12,5
+	tests := []struct {
+		name             string
+		from             *player.Position
+		enemies, friends []*player.Tank
+		target           *player.Position
+		fire             bool
+		dir              player.Direction
+	}{
+		{"nothing", pos(5, 5), nil, nil, nil, false, 0},
+		{"enemy up", pos(5, 5), []*player.Tank{tank(2, 2, 5)}, nil, nil, true, player.Direction_UP},
+		{"enemy down", pos(5, 5), []*player.Tank{tank(2, 8, 5)}, nil, nil, true, player.Direction_DOWN},
+		{"enemy left", pos(5, 5), []*player.Tank{tank(2, 5, 2)}, nil, nil, true, player.Direction_LEFT},
+		{"enemy right", pos(5, 5), []*player.Tank{tank(2, 5, 8)}, nil, nil, true, player.Direction_RIGHT},
+		{"enemy far", pos(5, 5), []*player.Tank{tank(2, 5, 17)}, nil, nil, false, 0},
+		{"enemy off line", pos(5, 5), []*player.Tank{tank(2, 6, 6)}, nil, nil, false, 0},
+		{"enemy behind a barrier", pos(5, 5), []*player.Tank{tank(2, 14, 5)}, nil, nil, false, 0},
+		{"past half the board", pos(20, 20), []*player.Tank{tank(2, 20, 23)}, nil, nil, true, player.Direction_RIGHT},
+		{"nearest enemy", pos(5, 5), []*player.Tank{tank(2, 5, 8), tank(3, 5, 4)}, nil, nil, true, player.Direction_LEFT},
+		{"friend in the way", pos(5, 5), []*player.Tank{tank(2, 5, 8)}, []*player.Tank{tank(3, 5, 7)}, nil, false, 0},
+		{"target", pos(5, 5), nil, nil, pos(5, 7), true, player.Direction_RIGHT},
+		{"target far", pos(5, 5), nil, nil, pos(5, 9), false, 0},
+	}
+	for _, test := range tests {
+		me := tank(1, test.from.X, test.from.Y)
+		tanks := append(append([]*player.Tank{me}, test.friends...), test.enemies...)
+		m := openMap(30)
+		m[12][5] = 1
+		w := testWorld(m, tanks, nil)
+		w.Args.ShellSpeed = 2
+		for _, f := range test.friends {
+			w.Tanks = append(w.Tanks, f.ID)
+		}
+		dir, fire := Fire(w, me.Pos, test.target)
+		if fire != test.fire || fire && dir != test.dir {
+			t.Errorf("%s: Fire gave %v %v, want %v %v", test.name, dir, fire, test.dir, test.fire)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package bot
+
+import "github.com/eleme/purchaseMeiTuan/player"
+
+// GrassScanner walks the forest cells near the flag, the hiding places of
+// the enemy. The cells are split in the quarter on my side of the flag and
+// the quarter on the enemy side, each list is walked in a loop.
+type GrassScanner struct {
+	mine, enemy  []*grassCell
+	myCurrent    *grassCell
+	enemyCurrent *grassCell
+}
+
+// grassCell is a hiding place, next is the index of the following cell of
+// its list and wraps to 0 at the end.
+type grassCell struct {
+	pos  *player.Position
+	next int
+	mine bool
+}
+
+// NewGrassScanner finds the hiding places as seen from pos, the position of
+// one of my tanks at the start of the match.
+func NewGrassScanner(w *World, pos *player.Position) *GrassScanner {
+	g := &GrassScanner{}
+	g.mine = scanGrass(w, pos, true)
+	g.enemy = scanGrass(w, pos, false)
+
+	if len(g.enemy) > 0 {
+		g.enemyCurrent = g.enemy[0]
+		if len(g.mine) == 0 {
+			g.myCurrent = g.enemy[0]
+		}
+	}
+	if len(g.mine) > 0 {
+		g.myCurrent = g.mine[0]
+		if len(g.enemy) == 0 {
+			g.enemyCurrent = g.mine[0]
+		}
+	}
+	return g
+}
+
+// Len returns the number of hiding places on my side and on the enemy side.
+func (g *GrassScanner) Len() (mine, enemy int) {
+	return len(g.mine), len(g.enemy)
+}
+
+// SweepTarget returns the hiding place a tank at pos hunting in the forest
+// goes to. It moves on to the next place once the tank reaches the current
+// one on my side.
+func (g *GrassScanner) SweepTarget(pos *player.Position) *player.Position {
+	if pos.X == g.myCurrent.pos.X && pos.Y == g.myCurrent.pos.Y {
+		g.nextEnemy()
+	}
+	return g.enemyCurrent.pos
+}
+
+// HideTarget returns the hiding place near the flag a tank at pos goes to.
+func (g *GrassScanner) HideTarget(pos *player.Position) *player.Position {
+	if pos.X == g.myCurrent.pos.X && pos.Y == g.myCurrent.pos.Y {
+		g.nextMine()
+	}
+	return g.enemyCurrent.pos
+}
+
+func (g *GrassScanner) nextEnemy() {
+	c := g.enemyCurrent
+	if c.next != 0 {
+		if c.mine {
+			g.enemyCurrent = g.mine[c.next]
+		} else {
+			g.enemyCurrent = g.enemy[c.next]
+		}
+		return
+	}
+	// 一轮走完，换到另一边
+	if c.mine {
+		if len(g.enemy) > 0 {
+			g.enemyCurrent = g.enemy[0]
+		} else {
+			g.enemyCurrent = g.mine[0]
+		}
+	} else {
+		if len(g.mine) > 0 {
+			g.enemyCurrent = g.mine[0]
+		} else {
+			g.enemyCurrent = g.enemy[0]
+		}
+	}
+}
+
+func (g *GrassScanner) nextMine() {
+	if len(g.mine) > 0 {
+		g.myCurrent = g.mine[g.myCurrent.next]
+	} else {
+		g.myCurrent = g.enemy[g.myCurrent.next]
+	}
+}
+
+// scanGrass lists the hiding places of one side, from the flag outwards.
+func scanGrass(w *World, pos *player.Position, isMine bool) []*grassCell {
+	start, end := quarter(w, pos, isMine)
+	cells := []*grassCell{}
+	for i := start.X; ; {
+		for j := start.Y; ; {
+			p := &player.Position{X: i, Y: j}
+			if isGrass(w, p) {
+				cells = append(cells, &grassCell{pos: p, next: len(cells) + 1, mine: isMine})
+			}
+			if end.Y >= start.Y {
+				j++
+				if j > end.Y {
+					break
+				}
+			} else {
+				j--
+				if j < end.Y {
+					break
+				}
+			}
+		}
+		if end.X >= start.X {
+			i++
+			if i > end.X {
+				break
+			}
+		} else {
+			i--
+			if i < end.X {
+				break
+			}
+		}
+	}
+	if len(cells) > 0 {
+		cells[len(cells)-1].next = 0
+	}
+	return cells
+}
+
+// quarter returns the corners of the quarter of one side, start next to the
+// flag. Like the bot of the competition it works on half the width of the
+// board, so the quarters are those of the top left of the board.
+func quarter(w *World, pos *player.Position, isMine bool) (start, end *player.Position) {
+	half := int32(w.Size() / 2)
+	start, end = &player.Position{}, &player.Position{}
+	if (pos.X < half/2) == isMine {
+		start.X, end.X = half/2-1, 0
+	} else {
+		start.X, end.X = half/2, half-1
+	}
+	if (pos.Y < half/2) == isMine {
+		start.Y, end.Y = half/2-1, 0
+	} else {
+		start.Y, end.Y = half/2, half-1
+	}
+	return start, end
+}
+
+// isGrass reports whether an enemy can hide on pos: a forest cell, or an
+// empty corner between two barriers.
+func isGrass(w *World, pos *player.Position) bool {
+	half := int32(w.Size() / 2)
+	x, y := int(pos.X), int(pos.Y)
+	switch w.Cell(x, y) {
+	case 2:
+		return true
+	case 0:
+		up := pos.X-1 >= 0 && w.Cell(x-1, y) == 1
+		down := pos.X+1 < half && w.Cell(x+1, y) == 1
+		left := pos.Y-1 >= 0 && w.Cell(x, y-1) == 1
+		right := pos.Y+1 < half && w.Cell(x, y+1) == 1
+		return (up || down) && (left || right)
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+func TestGrassSweep(t *testing.T) {
+	// on a board of 20 the quarters are 0 to 4 on my side and 5 to 9 on the
+	// enemy side; 1,1 is a corner between two barriers
+	tests := []struct {
+		name        string
+		forest      [][2]int32
+		mine, enemy int
+		// sweep are the targets given each time the tank reaches the
+		// first hiding place of my side.
+		sweep [][2]int32
+	}{
+		{"both sides", [][2]int32{{3, 3}, {1, 4}, {6, 6}, {8, 7}}, 3, 2,
+			[][2]int32{{8, 7}, {3, 3}, {1, 4}, {1, 1}, {6, 6}, {8, 7}}},
+		{"my side only", [][2]int32{{3, 3}}, 2, 0,
+			[][2]int32{{1, 1}, {3, 3}, {1, 1}}},
+	}
+	for _, test := range tests {
+		m := openMap(20)
+		for _, f := range test.forest {
+			m[f[0]][f[1]] = 2
+		}
+		tank := &player.Tank{ID: 1, Pos: &player.Position{X: 1, Y: 1}, Dir: player.Direction_DOWN, Hp: 1}
+		w := testWorld(m, []*player.Tank{tank}, nil)
+		g := NewGrassScanner(w, tank.Pos)
+		if mine, enemy := g.Len(); mine != test.mine || enemy != test.enemy {
+			t.Errorf("%s: %d and %d hiding places, want %d and %d", test.name, mine, enemy, test.mine, test.enemy)
+			continue
+		}
+		at := &player.Position{X: test.forest[0][0], Y: test.forest[0][1]}
+		// away from it the target stays
+		first := g.SweepTarget(tank.Pos)
+		if again := g.SweepTarget(tank.Pos); *again != *first {
+			t.Errorf("%s: the target moved from %v to %v", test.name, first, again)
+		}
+		for i, want := range test.sweep {
+			if got := g.SweepTarget(at); got.X != want[0] || got.Y != want[1] {
+				t.Errorf("%s: target %d is %v, want %v", test.name, i, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package bot
+
+import "github.com/eleme/purchaseMeiTuan/player"
+
+// History keeps the state of every round of a match, as I saw it. A
+// History never changes: the session adds a round by making a new one, so
+// the World of an earlier round keeps its own view of the match.
+type History struct {
+	// states holds the state of round i at index i.
+	states []*player.GameState
+}
+
+// newHistory returns an empty history with room for a match of maxRound
+// rounds.
+func newHistory(maxRound int) *History {
+	if maxRound <= 0 {
+		maxRound = 100
+	}
+	return &History{states: make([]*player.GameState, 0, maxRound)}
+}
+
+// add returns the history with the state of the next round. Only the
+// latest history may be added to, the views share their backing array.
+func (h *History) add(state *player.GameState) *History {
+	return &History{states: append(h.states, state)}
+}
+
+// Len returns the number of rounds in the history.
+func (h *History) Len() int {
+	return len(h.states)
+}
+
+// State returns the state of a round, nil if the round is not in the
+// history.
+func (h *History) State(round int) *player.GameState {
+	if round < 0 || round >= len(h.states) {
+		return nil
+	}
+	return h.states[round]
+}
+
+// LastSeen returns the tank as it was the last time it was in sight, and
+// the round of that time. ok is false if the tank was never seen.
+func (h *History) LastSeen(id int32) (tank *player.Tank, round int, ok bool) {
+	for round = len(h.states) - 1; round >= 0; round-- {
+		for _, t := range h.states[round].Tanks {
+			if t.ID == id {
+				return t, round, true
+			}
+		}
+	}
+	return nil, 0, false
+}
+
+// RoundsSinceFlagCaptured returns how many rounds ago either side last
+// captured the flag. ok is false if nobody has yet.
+func (h *History) RoundsSinceFlagCaptured() (rounds int, ok bool) {
+	for round := len(h.states) - 1; round > 0; round-- {
+		now, before := h.states[round], h.states[round-1]
+		if now.YourFlagNo+now.EnemyFlagNo > before.YourFlagNo+before.EnemyFlagNo {
+			return len(h.states) - 1 - round, true
+		}
+	}
+	return 0, false
+}
+
+// ShellsFired returns the number of shells the tank fired that I saw. A
+// shell carries the id of its tank, and a tank has one shell in flight at
+// most: a shell is new when the shell of the tank in the round before was
+// not in sight, or flew another way.
+func (h *History) ShellsFired(id int32) int {
+	fired := 0
+	var last *player.Shell
+	for _, state := range h.states {
+		var shell *player.Shell
+		for _, s := range state.Shells {
+			if s.ID == id {
+				shell = s
+				break
+			}
+		}
+		if shell != nil && (last == nil || !ahead(last, shell)) {
+			fired++
+		}
+		last = shell
+	}
+	return fired
+}
+
+// ahead reports whether the shell now is where the shell before would have
+// flown on.
+func ahead(before, now *player.Shell) bool {
+	if before.Dir != now.Dir {
+		return false
+	}
+	dx, dy := now.Pos.X-before.Pos.X, now.Pos.Y-before.Pos.Y
+	switch now.Dir {
+	case player.Direction_UP:
+		return dy == 0 && dx < 0
+	case player.Direction_DOWN:
+		return dy == 0 && dx > 0
+	case player.Direction_LEFT:
+		return dx == 0 && dy < 0
+	case player.Direction_RIGHT:
+		return dx == 0 && dy > 0
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+// historyStates are six rounds: enemy 2 is seen twice and fires two
+// shells, I take the flag in round 2 and the enemy in round 4, enemy 3
+// turns up in round 3 and fires in round 5.
+func historyStates() []*player.GameState {
+	pos := func(x, y int32) *player.Position { return &player.Position{X: x, Y: y} }
+	return []*player.GameState{
+		{Tanks: []*player.Tank{{ID: 2, Pos: pos(3, 3)}}},
+		{Tanks: []*player.Tank{{ID: 2, Pos: pos(3, 4)}}, Shells: []*player.Shell{{ID: 2, Pos: pos(4, 4), Dir: player.Direction_DOWN}}},
+		{Shells: []*player.Shell{{ID: 2, Pos: pos(6, 4), Dir: player.Direction_DOWN}}, YourFlagNo: 1},
+		{Tanks: []*player.Tank{{ID: 3, Pos: pos(8, 8)}}, YourFlagNo: 1},
+		{Shells: []*player.Shell{{ID: 2, Pos: pos(2, 2), Dir: player.Direction_RIGHT}}, YourFlagNo: 1, EnemyFlagNo: 1},
+		{Shells: []*player.Shell{
+			{ID: 2, Pos: pos(2, 4), Dir: player.Direction_RIGHT},
+			{ID: 3, Pos: pos(8, 7), Dir: player.Direction_LEFT},
+		}, YourFlagNo: 1, EnemyFlagNo: 1},
+	}
+}
+
+func TestHistory(t *testing.T) {
+	tests := []struct {
+		// rounds is the length of the history.
+		rounds int
+		// seen2 and seen3 are the rounds the tanks were last seen in, -1
+		// if never.
+		seen2, seen3 int
+		// flag is how many rounds ago the flag was last taken, -1 if never.
+		flag           int
+		fired2, fired3 int
+	}{
+		{1, 0, -1, -1, 0, 0},
+		{2, 1, -1, -1, 1, 0},
+		{3, 1, -1, 0, 1, 0},
+		{4, 1, 3, 1, 1, 0},
+		{5, 1, 3, 0, 2, 0},
+		{6, 1, 3, 1, 2, 1},
+	}
+	states := historyStates()
+	for _, test := range tests {
+		h := newHistory(0)
+		for _, state := range states[:test.rounds] {
+			h = h.add(state)
+		}
+		if h.Len() != test.rounds {
+			t.Errorf("%d rounds: Len %d", test.rounds, h.Len())
+		}
+		if h.State(test.rounds-1) != states[test.rounds-1] || h.State(test.rounds) != nil || h.State(-1) != nil {
+			t.Errorf("%d rounds: State does not give the rounds added", test.rounds)
+		}
+		for id, want := range map[int32]int{2: test.seen2, 3: test.seen3} {
+			tank, round, ok := h.LastSeen(id)
+			switch {
+			case want < 0 && ok:
+				t.Errorf("%d rounds: tank %d last seen in round %d, want never", test.rounds, id, round)
+			case want >= 0 && (!ok || round != want || tank != states[want].Tanks[0]):
+				t.Errorf("%d rounds: tank %d last seen in round %d (%v), want %d", test.rounds, id, round, ok, want)
+			}
+		}
+		if rounds, ok := h.RoundsSinceFlagCaptured(); ok != (test.flag >= 0) || ok && rounds != test.flag {
+			t.Errorf("%d rounds: flag captured %d rounds ago (%v), want %d", test.rounds, rounds, ok, test.flag)
+		}
+		if fired := h.ShellsFired(2); fired != test.fired2 {
+			t.Errorf("%d rounds: tank 2 fired %d shells, want %d", test.rounds, fired, test.fired2)
+		}
+		if fired := h.ShellsFired(3); fired != test.fired3 {
+			t.Errorf("%d rounds: tank 3 fired %d shells, want %d", test.rounds, fired, test.fired3)
+		}
+	}
+}
+
+// TestHistorySnapshot checks that the World of a round keeps its history
+// when the session goes on to the next rounds.
+func TestHistorySnapshot(t *testing.T) {
+	defer quiet()()
+	s := planSession(t, strategyFunc(func(w *World) []*player.Order { return nil }), 200)
+	defer s.Close()
+	states := historyStates()
+	s.LatestState(states[0])
+	s.LatestState(states[1])
+	w := s.world
+	for _, state := range states[2:] {
+		s.LatestState(state)
+	}
+
+	if w.History.Len() != 2 || w.History.State(2) != nil {
+		t.Errorf("the history of round 1 has %d rounds, want 2", w.History.Len())
+	}
+	if _, ok := w.History.RoundsSinceFlagCaptured(); ok {
+		t.Error("the history of round 1 has the flag taken")
+	}
+	if fired := w.History.ShellsFired(2); fired != 1 {
+		t.Errorf("the history of round 1 has tank 2 fire %d shells, want 1", fired)
+	}
+	if s.world.History.Len() != len(states) {
+		t.Errorf("the latest history has %d rounds, want %d", s.world.History.Len(), len(states))
+	}
+}
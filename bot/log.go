@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+)
+
+// The log levels, a message is logged when its level is at least the one
+// set by SetLogLevel.
+const (
+	levelDebug = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var levels = map[string]int32{
+	"debug": levelDebug,
+	"info":  levelInfo,
+	"warn":  levelWarn,
+	"error": levelError,
+}
+
+// logLevel is read by every goroutine logging, it is set atomically.
+var logLevel int32 = levelInfo
+
+// SetLogLevel sets the level of the logs of the package: debug, info, warn
+// or error.
+func SetLogLevel(name string) error {
+	level, ok := levels[name]
+	if !ok {
+		return fmt.Errorf("unknown log level %q, the levels are debug, info, warn and error", name)
+	}
+	atomic.StoreInt32(&logLevel, level)
+	return nil
+}
+
+func debugf(format string, v ...interface{}) { logf(levelDebug, "Debug: ", format, v...) }
+func infof(format string, v ...interface{})  { logf(levelInfo, "", format, v...) }
+func warnf(format string, v ...interface{})  { logf(levelWarn, "Warning: ", format, v...) }
+func errorf(format string, v ...interface{}) { logf(levelError, "Error: ", format, v...) }
+
+func logf(level int32, prefix, format string, v ...interface{}) {
+	if level >= atomic.LoadInt32(&logLevel) {
+		log.Output(3, prefix+fmt.Sprintf(format, v...))
+	}
+}
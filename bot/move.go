@@ -0,0 +1,156 @@
+package bot
+
+import (
+	"astar"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+// Mover plans the moves of my tanks for one round. It runs A* on the board
+// with the tanks and the cells a tank stepping in this round gets shot on as
+// barriers, and keeps the cells the tanks already moving this round will
+// step into so that two of my tanks do not bump into each other.
+type Mover struct {
+	w    *World
+	grid [50][50]int32
+	// paths is grid for the path search, built once for every tank.
+	paths *astar.Grid
+	// blocked are the cells of the board grid blocks beyond the map, and
+	// fields the fields of w without them, made when a tank first needs one.
+	blocked []*player.Position
+	fields  map[*Field]*Field
+	// steps are the cells claimed by the moves given so far.
+	steps []*player.Position
+}
+
+// NewMover returns the movement planner of the round of w, shots are the
+// shells of w.
+func NewMover(w *World, shots *Shots) *Mover {
+	m := &Mover{w: w, steps: []*player.Position{}}
+	for x := range m.grid {
+		for y := range m.grid[x] {
+			m.grid[x][y] = -1
+			if x < len(w.Map) && y < len(w.Map[x]) {
+				m.grid[x][y] = w.Map[x][y]
+			}
+			// a tank stepping in is hit by a shell stopping there this
+			// round, or flying over it the next before it can move on
+			pos := &player.Position{X: int32(x), Y: int32(y)}
+			if shots.Lands(pos, 1) || shots.Hit(pos, 2) {
+				m.block(x, y)
+			}
+		}
+	}
+	for _, t := range w.State.Tanks {
+		m.block(int(t.Pos.X), int(t.Pos.Y))
+	}
+	m.paths = astar.InitGrid(m.grid)
+	for x := range w.Map {
+		for y := range w.Map[x] {
+			if m.grid[x][y] == 1 && w.Map[x][y] != 1 {
+				m.blocked = append(m.blocked, &player.Position{X: int32(x), Y: int32(y)})
+			}
+		}
+	}
+	return m
+}
+
+func (m *Mover) block(x, y int) {
+	if x >= 0 && x < len(m.grid) && y >= 0 && y < len(m.grid[x]) {
+		m.grid[x][y] = 1
+	}
+}
+
+// Blocked reports whether a tank stands on pos or a tank stepping onto it
+// this round gets shot.
+func (m *Mover) Blocked(pos *player.Position) bool {
+	x, y := int(pos.X), int(pos.Y)
+	return x >= 0 && x < len(m.grid) && y >= 0 && y < len(m.grid[x]) && m.grid[x][y] == 1
+}
+
+// MoveTo returns the order taking the tank one step towards dest. The tank
+// turns first when it does not face the next step, and stays where it is
+// when it is there already, there is no path or another of my tanks takes
+// the cell.
+func (m *Mover) MoveTo(tank *player.Tank, dest *player.Position) *player.Order {
+	next, found := m.next(tank, dest)
+	if !found {
+		return &player.Order{TankId: tank.ID, Order: "turnTo", Dir: tank.Dir}
+	}
+	nextX, nextY := int(next.X), int(next.Y)
+	dir := Dir(tank.Pos, next)
+	facing := dir == tank.Dir
+
+	if m.w.Cell(nextX, nextY) == 1 {
+		if (dir == player.Direction_UP || dir == player.Direction_DOWN) && facing {
+			return &player.Order{TankId: tank.ID, Order: "turnTo", Dir: player.Direction_RIGHT}
+		}
+		if (dir == player.Direction_LEFT || dir == player.Direction_RIGHT) && facing {
+			return &player.Order{TankId: tank.ID, Order: "turnTo", Dir: player.Direction_UP}
+		}
+		return &player.Order{TankId: tank.ID, Order: "move", Dir: tank.Dir}
+	}
+
+	if !facing {
+		return &player.Order{TankId: tank.ID, Order: "turnTo", Dir: dir}
+	}
+	if len(m.steps) == 0 {
+		m.steps = append(m.steps, next)
+	} else {
+		n := len(m.steps)
+		for i := 0; i < n; i++ {
+			if m.steps[i].X == next.X && m.steps[i].Y == next.Y {
+				return &player.Order{TankId: tank.ID, Order: "turnTo", Dir: dir}
+			}
+			m.steps = append(m.steps, next)
+		}
+	}
+	return &player.Order{TankId: tank.ID, Order: "move", Dir: dir}
+}
+
+// next returns the cell next to the tank on a shortest path to dest, found
+// is false if the tank is on dest or there is no path. The path is looked up
+// in the field of dest when the map has one, and searched otherwise; both
+// cost the same, but may part where paths cost alike.
+func (m *Mover) next(tank *player.Tank, dest *player.Position) (next *player.Position, found bool) {
+	if f := m.w.Fields.To(dest); f != nil {
+		g, ok := m.fields[f]
+		if !ok {
+			if m.fields == nil {
+				m.fields = map[*Field]*Field{}
+			}
+			g = f.Without(m.blocked)
+			m.fields[f] = g
+		}
+		return g.Next(tank.Pos)
+	}
+	x, y := int(dest.X), int(dest.Y)
+	if x < 0 || x >= m.paths.Width || y < 0 || y >= m.paths.Height {
+		return nil, false
+	}
+	// dest is open even with a tank on it, the killer goes after one
+	cost := m.paths.Cost(x, y)
+	m.paths.SetCost(x, y, astar.KindCosts[astar.KindTo])
+	p, _, found := m.paths.Path(int(tank.Pos.X), int(tank.Pos.Y), x, y)
+	m.paths.SetCost(x, y, cost)
+	if !found || len(p) < 2 {
+		return nil, false
+	}
+	// the path goes from dest back to the tank
+	nextX, nextY := m.paths.XY(p[len(p)-2])
+	return &player.Position{X: int32(nextX), Y: int32(nextY)}, true
+}
+
+// Dir returns the direction from pos to the neighbouring cell next.
+func Dir(pos, next *player.Position) player.Direction {
+	if next.X == pos.X {
+		if next.Y > pos.Y {
+			return player.Direction_RIGHT
+		}
+		return player.Direction_LEFT
+	}
+	if next.X > pos.X {
+		return player.Direction_DOWN
+	}
+	return player.Direction_UP
+}
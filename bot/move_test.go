@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+func TestMover(t *testing.T) {
+	pos := func(x, y int32) *player.Position { return &player.Position{X: x, Y: y} }
+	tests := []struct {
+		name   string
+		dir    player.Direction
+		dest   *player.Position
+		shells []*player.Shell
+		// fields looks the way up in the fields of the map.
+		fields bool
+		want   player.Order
+	}{
+		{"move", player.Direction_RIGHT, pos(5, 8), nil, false, player.Order{Order: "move", Dir: player.Direction_RIGHT}},
+		{"turn first", player.Direction_UP, pos(5, 8), nil, false, player.Order{Order: "turnTo", Dir: player.Direction_RIGHT}},
+		{"on dest", player.Direction_UP, pos(5, 5), nil, false, player.Order{Order: "turnTo", Dir: player.Direction_UP}},
+		{"off the board", player.Direction_UP, pos(60, 5), nil, false, player.Order{Order: "turnTo", Dir: player.Direction_UP}},
+		// the shell lands on 4,6 in round 1 and flies over 5,6 in round 2,
+		// the way goes round below
+		{"shot cell", player.Direction_RIGHT, pos(5, 8), []*player.Shell{{ID: 9, Pos: pos(3, 6), Dir: player.Direction_DOWN}}, false, player.Order{Order: "turnTo", Dir: player.Direction_DOWN}},
+		{"field", player.Direction_LEFT, pos(5, 8), nil, true, player.Order{Order: "move", Dir: player.Direction_LEFT}},
+		{"field turn first", player.Direction_DOWN, pos(5, 8), nil, true, player.Order{Order: "turnTo", Dir: player.Direction_LEFT}},
+	}
+	for _, test := range tests {
+		tank := &player.Tank{ID: 1, Pos: pos(5, 5), Dir: test.dir, Hp: 1}
+		w := testWorld(openMap(10), []*player.Tank{tank}, test.shells)
+		if test.fields {
+			// the tank goes from dest to the flag, 5,5 on a board of 10,
+			// which has a field
+			w.Fields = NewFields(w.Map)
+			tank.Pos, test.dest = test.dest, w.Center()
+		}
+		o := NewMover(w, PredictShots(w)).MoveTo(tank, test.dest)
+		if o.TankId != 1 || o.Order != test.want.Order || o.Dir != test.want.Dir {
+			t.Errorf("%s: got %s %v, want %s %v", test.name, o.Order, o.Dir, test.want.Order, test.want.Dir)
+		}
+	}
+}
+
+func TestMoverClaimsSteps(t *testing.T) {
+	// both tanks step onto 5,6 next
+	a := &player.Tank{ID: 1, Pos: &player.Position{X: 5, Y: 5}, Dir: player.Direction_RIGHT, Hp: 1}
+	b := &player.Tank{ID: 2, Pos: &player.Position{X: 4, Y: 6}, Dir: player.Direction_DOWN, Hp: 1}
+	w := testWorld(openMap(10), []*player.Tank{a, b}, nil)
+	w.Tanks = []int32{1, 2}
+	m := NewMover(w, PredictShots(w))
+
+	if o := m.MoveTo(a, &player.Position{X: 5, Y: 8}); o.Order != "move" {
+		t.Errorf("the first tank got %s, want move", o.Order)
+	}
+	if o := m.MoveTo(b, &player.Position{X: 8, Y: 6}); o.Order == "move" {
+		t.Error("the second tank moves onto the cell the first takes")
+	}
+}
+
+func TestMoverBlocked(t *testing.T) {
+	tank := &player.Tank{ID: 1, Pos: &player.Position{X: 5, Y: 5}, Dir: player.Direction_UP, Hp: 1}
+	shells := []*player.Shell{{ID: 9, Pos: &player.Position{X: 3, Y: 6}, Dir: player.Direction_DOWN}}
+	w := testWorld(openMap(10), []*player.Tank{tank}, shells)
+	m := NewMover(w, PredictShots(w))
+	tests := []struct {
+		x, y int32
+		want bool
+	}{
+		{5, 5, true}, // the tank
+		{4, 6, true}, // the shell lands there
+		{5, 6, true}, // and flies over the round after
+		{6, 6, false},
+		{0, 0, true}, // a barrier
+	}
+	for _, test := range tests {
+		if got := m.Blocked(&player.Position{X: test.x, Y: test.y}); got != test.want {
+			t.Errorf("Blocked(%d,%d) = %v, want %v", test.x, test.y, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+// A round leaves the strategy planShare of RoundTimeoutInMs from the call
+// of GetNewOrders, and the session gives up on it at sendShare. The rest is
+// for the network.
+const (
+	planShare = 0.8
+	sendShare = 0.9
+)
+
+// planning is the strategy working on one round. It starts as soon as
+// LatestState brings the round, the engine's bookkeeping until
+// GetNewOrders is free time for it.
+type planning struct {
+	w      *World
+	cancel context.CancelFunc
+	start  time.Time
+	// done is closed when the strategy returned orders.
+	done   chan struct{}
+	orders []*player.Order
+}
+
+func (p *planning) finished() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// startPlanning starts the strategy on w in its own goroutine. A strategy
+// still busy with an earlier round is left to finish and this round gets
+// no plan. The caller holds mu.
+func (s *Session) startPlanning(w *World) {
+	if s.planning != nil && !s.planning.finished() {
+		warnf("Round %d: the strategy is still busy with round %d", w.Round, s.planning.w.Round)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &planning{w: w, cancel: cancel, start: time.Now(), done: make(chan struct{})}
+	go func() {
+		defer cancel()
+		p.orders = s.run(ctx, w)
+		close(p.done)
+	}()
+	s.planning = p
+}
+
+// collect returns the orders of the round of w from p within the round
+// timeout. When the strategy overruns, or p is not planning the round, the
+// orders are those of Fallback.
+func collect(w *World, p *planning) []*player.Order {
+	if p == nil || p.w != w {
+		warnf("Round %d: no plan, falling back", w.Round)
+		return Fallback(w)
+	}
+
+	timeout := time.Duration(w.Args.RoundTimeoutInMs) * time.Millisecond
+	if timeout <= 0 {
+		<-p.done
+		return p.orders
+	}
+	stop := time.AfterFunc(time.Duration(float64(timeout)*planShare), p.cancel)
+	defer stop.Stop()
+	limit := time.Duration(float64(timeout) * sendShare)
+	giveUp := time.NewTimer(limit)
+	defer giveUp.Stop()
+	select {
+	case <-p.done:
+		return p.orders
+	case <-giveUp.C:
+		warnf("Round %d: the strategy overran %v, falling back", w.Round, limit)
+		go func() {
+			<-p.done
+			warnf("Round %d: the strategy took %v", w.Round, time.Since(p.start))
+		}()
+		return Fallback(w)
+	}
+}
+
+// run asks the strategy for its orders. A strategy which panics gets the
+// orders of Fallback instead of crashing the server.
+func (s *Session) run(ctx context.Context, w *World) (orders []*player.Order) {
+	defer func() {
+		if r := recover(); r != nil {
+			errorf("Round %d: the strategy panicked: %v", w.Round, r)
+			orders = Fallback(w)
+		}
+	}()
+	if cs, ok := s.strategy.(ContextStrategy); ok {
+		return cs.OrdersContext(ctx, w)
+	}
+	return s.strategy.Orders(w)
+}
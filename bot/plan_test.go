@@ -0,0 +1,224 @@
+package bot
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+// planOrders are the orders of the test strategies, Fallback never gives
+// them.
+var planOrders = []*player.Order{{TankId: 1, Order: "fire", Dir: player.Direction_RIGHT}}
+
+// strategyFunc is a Strategy calling itself.
+type strategyFunc func(w *World) []*player.Order
+
+func (f strategyFunc) Orders(w *World) []*player.Order {
+	return f(w)
+}
+
+// contextStrategy is a ContextStrategy calling itself.
+type contextStrategy func(ctx context.Context, w *World) []*player.Order
+
+func (f contextStrategy) Orders(w *World) []*player.Order {
+	return f(context.Background(), w)
+}
+
+func (f contextStrategy) OrdersContext(ctx context.Context, w *World) []*player.Order {
+	return f(ctx, w)
+}
+
+// planSession returns a session playing the strategy with the round timeout.
+func planSession(t *testing.T, strategy Strategy, timeoutMs int32) *Session {
+	s := &Session{config: Config{Strategy: "test", Name: "test"}, roundCount: -1, strategy: strategy}
+	if err := s.UploadMap(openMap(13)); err != nil {
+		t.Fatal(err)
+	}
+	s.UploadParamters(&player.Args_{TankSpeed: 1, ShellSpeed: 2, TankHP: 1, MaxRound: 50, RoundTimeoutInMs: timeoutMs})
+	s.AssignTanks([]int32{1})
+	return s
+}
+
+// planState is a round in which the shell hits my tank in round 2, so
+// Fallback steps it aside.
+func planState() *player.GameState {
+	return &player.GameState{
+		Tanks:  []*player.Tank{{ID: 1, Pos: &player.Position{X: 1, Y: 1}, Dir: player.Direction_DOWN, Hp: 1}},
+		Shells: []*player.Shell{{ID: 9, Pos: &player.Position{X: 1, Y: 4}, Dir: player.Direction_LEFT}},
+	}
+}
+
+// getOrders returns the orders of the round and the time GetNewOrders
+// took.
+func getOrders(t *testing.T, s *Session) ([]*player.Order, time.Duration) {
+	start := time.Now()
+	orders, err := s.GetNewOrders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return orders, time.Since(start)
+}
+
+// inTime returns the orders of the round, failing if the session waited
+// for the strategy until it gave up.
+func inTime(t *testing.T, s *Session) []*player.Order {
+	orders, took := getOrders(t, s)
+	if limit := time.Duration(float64(s.world.Args.RoundTimeoutInMs)*sendShare) * time.Millisecond; took >= limit {
+		t.Errorf("GetNewOrders took %v, the session gave up on the strategy", took)
+	}
+	return orders
+}
+
+// quiet turns the warnings off until the returned func is called.
+func quiet() func() {
+	SetLogLevel("error")
+	return func() { SetLogLevel("info") }
+}
+
+func TestPlanOverrun(t *testing.T) {
+	defer quiet()()
+	release := make(chan struct{})
+	defer close(release)
+	s := planSession(t, strategyFunc(func(w *World) []*player.Order {
+		<-release
+		return planOrders
+	}), 200)
+	defer s.Close()
+
+	s.LatestState(planState())
+	got, took := getOrders(t, s)
+	if want := Fallback(s.world); !reflect.DeepEqual(got, want) || len(got) == 0 {
+		t.Errorf("overrunning strategy: orders %v, want those of Fallback %v", got, want)
+	}
+	// the session gives up at sendShare, leaving the rest of the round to
+	// the network; the margin is for the scheduler
+	if limit := time.Duration(float64(200*time.Millisecond) * sendShare); took > limit+10*time.Millisecond {
+		t.Errorf("GetNewOrders took %v, want at most %v", took, limit)
+	}
+}
+
+func TestPlanPanic(t *testing.T) {
+	defer quiet()()
+	s := planSession(t, strategyFunc(func(w *World) []*player.Order {
+		panic("lost")
+	}), 200)
+	defer s.Close()
+
+	s.LatestState(planState())
+	if got, want := inTime(t, s), Fallback(s.world); !reflect.DeepEqual(got, want) {
+		t.Errorf("panicking strategy: orders %v, want those of Fallback %v", got, want)
+	}
+}
+
+func TestPlanContext(t *testing.T) {
+	defer quiet()()
+	const timeout = 500 * time.Millisecond
+	cancelled := make(chan time.Time, 1)
+	s := planSession(t, contextStrategy(func(ctx context.Context, w *World) []*player.Order {
+		<-ctx.Done()
+		cancelled <- time.Now()
+		return planOrders
+	}), int32(timeout/time.Millisecond))
+	defer s.Close()
+
+	s.LatestState(planState())
+	asked := time.Now()
+	got, _ := getOrders(t, s)
+	if !reflect.DeepEqual(got, planOrders) {
+		t.Errorf("orders %v, want those the strategy gave when cancelled", got)
+	}
+	at := (<-cancelled).Sub(asked)
+	plan := time.Duration(float64(timeout) * planShare)
+	if send := time.Duration(float64(timeout) * sendShare); at < plan || at >= send {
+		t.Errorf("the context was done after %v, want at %v, before the session gives up at %v", at, plan, send)
+	}
+}
+
+func TestPlanEarly(t *testing.T) {
+	defer quiet()()
+	started := make(chan int, 1)
+	s := planSession(t, strategyFunc(func(w *World) []*player.Order {
+		started <- w.Round
+		return planOrders
+	}), 200)
+	defer s.Close()
+
+	// the strategy works on the round before it is asked for orders
+	s.LatestState(planState())
+	select {
+	case round := <-started:
+		if round != 0 {
+			t.Errorf("planning round %d, want 0", round)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the strategy was not started by LatestState")
+	}
+	if got := inTime(t, s); !reflect.DeepEqual(got, planOrders) {
+		t.Errorf("orders %v, want those of the strategy", got)
+	}
+}
+
+func TestPlanBusy(t *testing.T) {
+	defer quiet()()
+	release := make(chan struct{})
+	var calls, running, overlaps int32
+	s := planSession(t, strategyFunc(func(w *World) []*player.Order {
+		atomic.AddInt32(&calls, 1)
+		if atomic.AddInt32(&running, 1) > 1 {
+			atomic.AddInt32(&overlaps, 1)
+		}
+		defer atomic.AddInt32(&running, -1)
+		<-release
+		return planOrders
+	}), 100)
+	defer s.Close()
+
+	// round 0 overruns
+	s.LatestState(planState())
+	getOrders(t, s)
+	// round 1 finds the strategy still at round 0 and falls back at once
+	s.LatestState(planState())
+	got := inTime(t, s)
+	if want := Fallback(s.world); !reflect.DeepEqual(got, want) {
+		t.Errorf("round 1: orders %v, want those of Fallback %v", got, want)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("round 1: the strategy was called %d times, want once", n)
+	}
+
+	// once round 0 is done round 2 is planned again
+	close(release)
+	s.mu.Lock()
+	p := s.planning
+	s.mu.Unlock()
+	<-p.done
+	s.LatestState(planState())
+	if got := inTime(t, s); !reflect.DeepEqual(got, planOrders) {
+		t.Errorf("round 2: orders %v, want those of the strategy", got)
+	}
+	if n := atomic.LoadInt32(&overlaps); n != 0 {
+		t.Errorf("the strategy ran %d times alongside itself", n)
+	}
+}
+
+func TestCollectStale(t *testing.T) {
+	defer quiet()()
+	w1 := &World{Map: openMap(13), Args: player.Args_{ShellSpeed: 2, RoundTimeoutInMs: 200}, Tanks: []int32{1}, State: planState()}
+	w2 := &World{Map: w1.Map, Args: w1.Args, Tanks: w1.Tanks, State: planState(), Round: 1}
+	p := &planning{w: w1, cancel: func() {}, done: make(chan struct{}), orders: planOrders}
+	close(p.done)
+
+	if got := collect(w1, p); !reflect.DeepEqual(got, planOrders) {
+		t.Errorf("collect of the round planned: %v, want %v", got, planOrders)
+	}
+	if got, want := collect(w2, p), Fallback(w2); !reflect.DeepEqual(got, want) {
+		t.Errorf("collect of another round: %v, want those of Fallback %v", got, want)
+	}
+	if got, want := collect(w2, nil), Fallback(w2); !reflect.DeepEqual(got, want) {
+		t.Errorf("collect with no plan: %v, want those of Fallback %v", got, want)
+	}
+}
@@ -0,0 +1,161 @@
+package bot
+
+import "github.com/eleme/purchaseMeiTuan/player"
+
+func init() {
+	Register(Default, func() Strategy { return &roles{} })
+	Register("grass", func() Strategy { return &roles{scanGrass: true} })
+	Register("hunt", func() Strategy { return &roles{scanGrass: true, tracker: NewTracker()} })
+	Register("team", func() Strategy { return &roles{scanGrass: true, tracker: NewTracker(), team: true} })
+}
+
+// roles is the strategy the team played in the competition. The roles go by
+// the index of the tank among my living tanks:
+//
+//	第一辆坦克，"杀手"职业，追着敌方坦克打。
+//	第二辆坦克，"夺旗"职业，围着地图中心点周围 9 * 9 的格子徘徊。
+//	第三辆坦克，"辅助"职业，围着地图中心点周围四分之一地图大小徘徊。
+//	第四辆坦克，"猥琐"职业，专门躲在草丛中，在草丛中游走。
+//
+// A tank which can hit an enemy fires instead of following its role.
+type roles struct {
+	// scanGrass turns on the grass sweep: the killer fires into the forest
+	// when it sees no enemy, and the fourth tank walks the forest near the
+	// flag. Without it those tanks wait.
+	scanGrass bool
+	scanned   bool
+	grass     *GrassScanner
+	// tracker, when set, sends the killer and the scanner after the cell an
+	// unseen enemy most likely hides in, the forest sweep is left for when
+	// it knows of none.
+	tracker *Tracker
+	// team plans the moves of my tanks together with PlanRoutes instead of
+	// one after the other with a Mover. The routes keep off the shells, so
+	// the tanks do not dodge, and a tank with nowhere to go waits.
+	team bool
+}
+
+// Orders implements Strategy.
+func (r *roles) Orders(w *World) []*player.Order {
+	if r.scanGrass && !r.scanned {
+		// 获取所有草地
+		if tanks := w.MyTanks(); len(tanks) > 0 {
+			r.grass = NewGrassScanner(w, tanks[0].Pos)
+		}
+		r.scanned = true
+	}
+	myGrasses, enemyGrasses := 0, 0
+	if r.grass != nil {
+		myGrasses, enemyGrasses = r.grass.Len()
+	}
+
+	var hideout *player.Position
+	if r.tracker != nil {
+		r.tracker.Update(w)
+		hideout, _ = r.tracker.Hottest(w)
+	}
+
+	orders := []*player.Order{}
+	shots := PredictShots(w)
+	mover := NewMover(w, shots)
+	enemies := w.Enemies()
+	var moving []*player.Tank
+	var dests []*player.Position
+	for i, tank := range w.MyTanks() {
+		// 如果两回合内会被子弹打中，立即躲避
+		if threats := shots.Threats(tank.Pos, 2); len(threats) > 0 && !r.team {
+			orders = append(orders, dodge(tank, threats)...)
+		}
+
+		if dir, ok := Fire(w, tank.Pos, hideout); ok {
+			orders = append(orders, &player.Order{TankId: tank.ID, Order: "fire", Dir: dir})
+			if r.team {
+				// the tank stays where it fires from, the others are
+				// routed round it
+				continue
+			}
+			// the bot of the competition gave no more orders once a tank
+			// fired
+			break
+		}
+		dest := r.dest(w, i, tank, enemies, hideout, myGrasses, enemyGrasses)
+		if r.team {
+			moving = append(moving, tank)
+			dests = append(dests, dest)
+		} else if dest != nil {
+			orders = append(orders, mover.MoveTo(tank, dest))
+		}
+	}
+	if r.team {
+		for i, route := range PlanRoutes(w, shots, moving, dests) {
+			if len(route) > 0 {
+				orders = append(orders, route[0])
+			} else if threats := shots.Threats(moving[i].Pos, 2); len(threats) > 0 {
+				// no route keeps the tank clear of the shells, dodge them
+				orders = append(orders, dodge(moving[i], threats)...)
+			}
+		}
+	}
+	return orders
+}
+
+// dest returns where the i-th of my tanks goes, nil if it waits.
+func (r *roles) dest(w *World, i int, tank *player.Tank, enemies []*player.Tank, hideout *player.Position, myGrasses, enemyGrasses int) *player.Position {
+	center := w.Center()
+	half := w.Size() / 2
+	switch i {
+	case 0: // 第一辆坦克 - 杀手
+		if len(enemies) > 0 {
+			return enemies[0].Pos
+		}
+		if hideout != nil {
+			return hideout
+		}
+		if r.scanGrass && myGrasses > 0 && enemyGrasses > 0 {
+			return r.grass.SweepTarget(tank.Pos)
+		}
+	case 1: // 第二辆坦克 - 夺旗
+		if tank.Pos.X == center.X && tank.Pos.Y == center.Y {
+			return &player.Position{X: center.X + int32(w.Rand.Intn(5)-2), Y: center.Y + int32(w.Rand.Intn(5)-2)}
+		}
+		return center
+	case 2: // 第三辆坦克 - 保护
+		return &player.Position{X: center.X + int32(w.Rand.Intn(half)/4-half/8), Y: center.Y + int32(w.Rand.Intn(half)/4-half/8)}
+	case 3: // 第四辆坦克 - 扫描
+		if !r.scanGrass {
+			break
+		}
+		if hideout != nil {
+			return hideout
+		}
+		if myGrasses > 0 || enemyGrasses > 0 {
+			return r.grass.HideTarget(tank.Pos)
+		}
+		if len(enemies) > 0 {
+			return enemies[0].Pos
+		}
+	}
+	return nil
+}
+
+// dodge 躲开会打中坦克的子弹：横着飞来的就竖着走，竖着飞来的就横着走
+func dodge(tank *player.Tank, shells []*player.Shell) []*player.Order {
+	orders := []*player.Order{}
+	for _, shell := range shells {
+		switch shell.Dir {
+		case player.Direction_UP, player.Direction_DOWN:
+			if tank.Dir == player.Direction_UP || tank.Dir == player.Direction_DOWN {
+				orders = append(orders, &player.Order{TankId: tank.ID, Order: "turnTo", Dir: player.Direction_RIGHT})
+			} else {
+				orders = append(orders, &player.Order{TankId: tank.ID, Order: "move", Dir: tank.Dir})
+			}
+		case player.Direction_LEFT, player.Direction_RIGHT:
+			if tank.Dir == player.Direction_LEFT || tank.Dir == player.Direction_RIGHT {
+				orders = append(orders, &player.Order{TankId: tank.ID, Order: "turnTo", Dir: player.Direction_UP})
+			} else {
+				orders = append(orders, &player.Order{TankId: tank.ID, Order: "move", Dir: tank.Dir})
+			}
+		}
+	}
+	return orders
+}
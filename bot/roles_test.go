@@ -0,0 +1,38 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+func TestRolesFire(t *testing.T) {
+	tests := []struct {
+		strategy string
+		// orders is how many orders my tanks get.
+		orders int
+	}{
+		// the bot of the competition stops once a tank fires
+		{Default, 1},
+		// the second tank goes on to the flag
+		{"team", 2},
+	}
+	for _, test := range tests {
+		s, err := New(test.strategy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tanks := []*player.Tank{
+			{ID: 1, Pos: &player.Position{X: 2, Y: 2}, Dir: player.Direction_DOWN, Hp: 1},
+			{ID: 2, Pos: &player.Position{X: 8, Y: 8}, Dir: player.Direction_DOWN, Hp: 1},
+			{ID: 3, Pos: &player.Position{X: 2, Y: 5}, Dir: player.Direction_LEFT, Hp: 1},
+		}
+		w := testWorld(openMap(20), tanks, nil)
+		w.Args.ShellSpeed = 2
+		w.Tanks = []int32{1, 2}
+		orders := s.Orders(w)
+		if len(orders) != test.orders || orders[0].TankId != 1 || orders[0].Order != "fire" {
+			t.Errorf("%s: orders %v, want tank 1 to fire and %d orders", test.strategy, orders, test.orders)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package bot
+
+import (
+	"astar"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+// directions are the four directions a tank faces, in the order the route
+// search tries them.
+var directions = [4]player.Direction{
+	player.Direction_UP,
+	player.Direction_DOWN,
+	player.Direction_LEFT,
+	player.Direction_RIGHT,
+}
+
+// Route returns the orders taking the tank to dest in the fewest rounds
+// without being shot, one order a round. It searches the cells, the way the
+// tank faces and the round: a turn takes a round, a move goes TankSpeed
+// cells the way the tank faces, and a tank may stay where it is, given as
+// turning to the way it already faces. The tank is never on a cell in a
+// round shots hits it, nor moves onto one a shell stops on, and it only
+// ends on dest once no shell comes by it any more.
+//
+// The other tanks are taken to stay where they are, but a move running into
+// one is left out: the tank may go and the move go further. A tank on dest
+// cannot be reached, the route ends next to it. The shells hitting the tank
+// in the coming round are not looked at, it cannot get away from them.
+// found is false if the tank cannot reach dest.
+func Route(w *World, shots *Shots, tank *player.Tank, dest *player.Position) (orders []*player.Order, found bool) {
+	orders, _, found = route(w, shots, tank, dest, nil)
+	return orders, found
+}
+
+// route is Route keeping off the cells res holds, the tanks with a
+// reservation are not obstacles. cells[i] is where the tank is after round
+// i, cells[0] where it is now.
+func route(w *World, shots *Shots, tank *player.Tank, dest *player.Position, res *reservations) (orders []*player.Order, cells []*player.Position, found bool) {
+	r := &router{w: w, shots: shots, tank: tank, dest: dest, tanks: map[[2]int]bool{}, res: res}
+	for _, t := range w.State.Tanks {
+		if t.ID != tank.ID && !res.has(t.ID) {
+			r.tanks[[2]int{int(t.Pos.X), int(t.Pos.Y)}] = true
+		}
+	}
+	r.near = r.tanks[[2]int{int(dest.X), int(dest.Y)}]
+	r.horizon = shots.Horizon()
+	if res.horizon() > r.horizon {
+		r.horizon = res.horizon()
+	}
+	start := routeNode{x: int(tank.Pos.X), y: int(tank.Pos.Y), dir: tank.Dir, r: r}
+	path, _, found := astar.Path(start, routeNode{goal: true, r: r})
+	if !found {
+		return nil, nil, false
+	}
+	// the path goes from the goal back to the tank
+	orders = []*player.Order{}
+	cells = []*player.Position{tank.Pos}
+	for i := len(path) - 1; i > 1; i-- {
+		from, to := path[i].(routeNode), path[i-1].(routeNode)
+		order := &player.Order{TankId: tank.ID, Order: "turnTo", Dir: to.dir}
+		if from.x != to.x || from.y != to.y {
+			order.Order = "move"
+		}
+		orders = append(orders, order)
+		cells = append(cells, &player.Position{X: int32(to.x), Y: int32(to.y)})
+	}
+	return orders, cells, true
+}
+
+// router holds what the nodes of one route search share.
+type router struct {
+	w     *World
+	shots *Shots
+	tank  *player.Tank
+	dest  *player.Position
+	// tanks are the cells of the other tanks.
+	tanks map[[2]int]bool
+	// near is true if a tank is on dest, the route ends next to it.
+	near bool
+	res  *reservations
+	// horizon is the last round a shell flies in or a cell is held in.
+	horizon int
+}
+
+// routeNode is the tank on x, y facing dir after round, or the goal. The
+// rounds after the horizon of the router are all alike and make one node.
+type routeNode struct {
+	x, y  int
+	dir   player.Direction
+	round int
+	goal  bool
+	r     *router
+}
+
+// PathNeighbors implements astar.Pather.
+func (n routeNode) PathNeighbors() []astar.Pather {
+	neighbors := []astar.Pather{}
+	if n.goal {
+		return neighbors
+	}
+	r := n.r
+	round := n.round + 1
+	// the tank stays on dest from the next round on, so no shell may come
+	// by it nor another tank hold it after
+	if r.distance(n.x, n.y) == 0 && r.res.free(n.x, n.y, round) && r.unhit(n.x, n.y, round) {
+		neighbors = append(neighbors, routeNode{goal: true, r: r})
+	}
+	// shells fly before the tanks act
+	if n.round > 0 && r.shots.Hit(&player.Position{X: int32(n.x), Y: int32(n.y)}, round) || r.res.held(n.x, n.y, round) {
+		return neighbors
+	}
+	next := round
+	if next > r.horizon+1 {
+		next = n.round
+	}
+	for _, dir := range directions {
+		if dir != n.dir || next != n.round {
+			neighbors = append(neighbors, routeNode{x: n.x, y: n.y, dir: dir, round: next, r: r})
+		}
+	}
+	if x, y, ok := r.move(n.x, n.y, n.dir, round); ok {
+		neighbors = append(neighbors, routeNode{x: x, y: y, dir: n.dir, round: next, r: r})
+	}
+	return neighbors
+}
+
+// move returns where the tank on x, y facing dir ends moving in the round.
+// It goes TankSpeed cells, stopping before a barrier, and ok is false if it
+// does not move, runs into a tank, steps on a shell or on a cell held in
+// the round.
+func (r *router) move(x, y int, dir player.Direction, round int) (int, int, bool) {
+	pos := &player.Position{X: int32(x), Y: int32(y)}
+	moved := false
+	for i := int32(0); i < r.w.Args.TankSpeed; i++ {
+		next := step(pos, dir)
+		if r.w.Cell(int(next.X), int(next.Y)) == 1 {
+			break
+		}
+		if r.tanks[[2]int{int(next.X), int(next.Y)}] || r.shots.Lands(next, round) || r.res.held(int(next.X), int(next.Y), round) {
+			return 0, 0, false
+		}
+		pos, moved = next, true
+	}
+	return int(pos.X), int(pos.Y), moved
+}
+
+// unhit reports whether no shell hits a tank staying on x, y from the round
+// on. The coming round is left out, the tank cannot get away from it.
+func (r *router) unhit(x, y, from int) bool {
+	pos := &player.Position{X: int32(x), Y: int32(y)}
+	if from < 2 {
+		from = 2
+	}
+	for round := from; round <= r.shots.Horizon(); round++ {
+		if r.shots.Hit(pos, round) {
+			return false
+		}
+	}
+	return true
+}
+
+// PathNeighborCost implements astar.Pather, every order takes a round.
+func (n routeNode) PathNeighborCost(to astar.Pather) float64 {
+	if to.(routeNode).goal {
+		return 0
+	}
+	return 1
+}
+
+// PathEstimatedCost implements astar.Pather with the moves the tank needs
+// to cover the distance to dest.
+func (n routeNode) PathEstimatedCost(to astar.Pather) float64 {
+	if n.goal {
+		return 0
+	}
+	speed := int(n.r.w.Args.TankSpeed)
+	if speed < 1 {
+		speed = 1
+	}
+	return float64((n.r.distance(n.x, n.y) + speed - 1) / speed)
+}
+
+// distance returns how many cells x, y is from the end of the route.
+func (r *router) distance(x, y int) int {
+	dx, dy := x-int(r.dest.X), y-int(r.dest.Y)
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	if r.near {
+		return dx + dy - 1
+	}
+	return dx + dy
+}
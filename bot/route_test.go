@@ -0,0 +1,106 @@
+package bot
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+// openMap returns a board of size cells a side, empty but for the barriers
+// around it.
+func openMap(size int) [][]int32 {
+	m := make([][]int32, size)
+	for x := range m {
+		m[x] = make([]int32, size)
+		for y := range m[x] {
+			if x == 0 || y == 0 || x == size-1 || y == size-1 {
+				m[x][y] = 1
+			}
+		}
+	}
+	return m
+}
+
+// testWorld returns the first round on the board with the tanks, the first
+// of them mine, and the shells. Tanks and shells go one cell a round.
+func testWorld(m [][]int32, tanks []*player.Tank, shells []*player.Shell) *World {
+	return &World{
+		Map:   m,
+		Args:  player.Args_{TankSpeed: 1, ShellSpeed: 1, TankHP: 1, MaxRound: 100},
+		Tanks: []int32{tanks[0].ID},
+		State: &player.GameState{Tanks: tanks, Shells: shells},
+		Rand:  rand.New(rand.NewSource(1)),
+	}
+}
+
+func TestRoute(t *testing.T) {
+	tests := []struct {
+		name  string
+		dir   player.Direction
+		dest  [2]int32
+		speed int32
+		// barriers and tanks are on the board besides my tank on 5, 5.
+		barriers [][2]int
+		tanks    [][2]int32
+		shells   []*player.Shell
+		found    bool
+		// min is the fewest orders the route may take.
+		min, max int
+		// end is the cell the route ends on, first the first order.
+		end   [2]int32
+		first string
+	}{
+		{"open", player.Direction_UP, [2]int32{4, 5}, 1, nil, nil, nil, true, 1, 1, [2]int32{4, 5}, "move"},
+		// the shell crosses dest in round 3, the tank must not be there
+		// before it has gone by
+		{"dest shot later", player.Direction_UP, [2]int32{4, 5}, 1, nil, nil,
+			[]*player.Shell{{ID: 9, Pos: &player.Position{X: 4, Y: 8}, Dir: player.Direction_LEFT}}, true, 3, 6, [2]int32{4, 5}, ""},
+		{"turn first", player.Direction_UP, [2]int32{5, 7}, 1, nil, nil, nil, true, 3, 3, [2]int32{5, 7}, "turnTo"},
+		// the move goes one cell of two and stops before the barrier
+		{"speed 2 barrier", player.Direction_UP, [2]int32{4, 5}, 2, [][2]int{{3, 5}}, nil, nil, true, 1, 1, [2]int32{4, 5}, "move"},
+		{"tank in the way", player.Direction_UP, [2]int32{3, 5}, 1, nil, [][2]int32{{4, 5}}, nil, true, 5, 7, [2]int32{3, 5}, "turnTo"},
+		{"walled in", player.Direction_UP, [2]int32{2, 2}, 1, [][2]int{{1, 2}, {3, 2}, {2, 1}, {2, 3}}, nil, nil, false, 0, 0, [2]int32{}, ""},
+		{"dest held", player.Direction_UP, [2]int32{3, 5}, 1, nil, [][2]int32{{3, 5}}, nil, true, 1, 1, [2]int32{4, 5}, "move"},
+	}
+	for _, test := range tests {
+		m := openMap(10)
+		for _, b := range test.barriers {
+			m[b[0]][b[1]] = 1
+		}
+		tank := &player.Tank{ID: 1, Pos: &player.Position{X: 5, Y: 5}, Dir: test.dir, Hp: 1}
+		tanks := []*player.Tank{tank}
+		others := map[[2]int32]bool{}
+		for i, pos := range test.tanks {
+			tanks = append(tanks, &player.Tank{ID: int32(i + 2), Pos: &player.Position{X: pos[0], Y: pos[1]}, Dir: player.Direction_UP, Hp: 1})
+			others[pos] = true
+		}
+		w := testWorld(m, tanks, test.shells)
+		w.Args.TankSpeed = test.speed
+		dest := &player.Position{X: test.dest[0], Y: test.dest[1]}
+		orders, found := Route(w, PredictShots(w), tank, dest)
+		if found != test.found || len(orders) < test.min || len(orders) > test.max {
+			t.Errorf("%s: Route found %v in %d orders, want %v in %d to %d", test.name, found, len(orders), test.found, test.min, test.max)
+			continue
+		}
+		if !found {
+			continue
+		}
+		if test.first != "" && orders[0].Order != test.first {
+			t.Errorf("%s: the first order is %s, want %s", test.name, orders[0].Order, test.first)
+		}
+		_, cells, _ := route(w, PredictShots(w), tank, dest, nil)
+		for i := 1; i < len(cells); i++ {
+			// the tank moves the way it faces over the cells in between
+			for pos := cells[i-1]; pos.X != cells[i].X || pos.Y != cells[i].Y; {
+				pos = step(pos, Dir(pos, cells[i]))
+				if others[[2]int32{pos.X, pos.Y}] || w.Cell(int(pos.X), int(pos.Y)) == 1 {
+					t.Errorf("%s: round %d runs into %v", test.name, i, pos)
+				}
+			}
+		}
+		if end := cells[len(cells)-1]; end.X != test.end[0] || end.Y != test.end[1] {
+			t.Errorf("%s: the route ends on %v, want %v", test.name, end, test.end)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"fmt"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+// Serve runs the thrift server of config. It returns when the server stops
+// or fails to start.
+func Serve(config Config) error {
+	if err := SetLogLevel(config.LogLevel); err != nil {
+		return err
+	}
+	if config.Name == "" {
+		config.Name = config.Addr
+	}
+	transportFactory, err := newTransportFactory(config.Transport, config.Protocol)
+	if err != nil {
+		return err
+	}
+	protocolFactory, err := newProtocolFactory(config.Protocol)
+	if err != nil {
+		return err
+	}
+	processorFactory, err := NewProcessorFactory(config)
+	if err != nil {
+		return err
+	}
+
+	serverTransport, err := thrift.NewTServerSocket(config.Addr)
+	if err != nil {
+		return err
+	}
+	server := thrift.NewTSimpleServerFactory4(processorFactory, serverTransport, transportFactory, protocolFactory)
+	infof("Running at: %s, %s transport, %s protocol, strategy %s", config.Addr, config.Transport, config.Protocol, config.Strategy)
+	return server.Serve()
+}
+
+func newTransportFactory(name, protocol string) (thrift.TTransportFactory, error) {
+	switch name {
+	case "buffered":
+		return thrift.NewTBufferedTransportFactory(bufferSize), nil
+	case "framed":
+		return thrift.NewTFramedTransportFactory(thrift.NewTTransportFactory()), nil
+	case "auto":
+		return newAutoTransportFactory(protocol), nil
+	}
+	return nil, fmt.Errorf("unknown transport %q, the transports are buffered, framed and auto", name)
+}
+
+func newProtocolFactory(name string) (thrift.TProtocolFactory, error) {
+	switch name {
+	case "binary":
+		return thrift.NewTBinaryProtocolFactoryDefault(), nil
+	case "compact":
+		return thrift.NewTCompactProtocolFactory(), nil
+	case "json":
+		return thrift.NewTJSONProtocolFactory(), nil
+	}
+	return nil, fmt.Errorf("unknown protocol %q, the protocols are binary, compact and json", name)
+}
@@ -0,0 +1,274 @@
+package bot
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"replay"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+// Session holds everything the bot knows about one match. Every match gets
+// its own session, so matches served by one process never share state.
+//
+// The handlers may be called concurrently and in any order. Each round is
+// published as a World, an immutable snapshot swapped under mu, so the
+// strategy and GetNewOrders read it without holding the lock.
+type Session struct {
+	config   Config
+	strategy Strategy
+	// rnd is the random source of the strategy.
+	rnd *rand.Rand
+
+	mu            sync.Mutex
+	gameArguments player.Args_
+	gameMap       [][]int32
+	fields        *Fields
+	myTankList    []int32
+	roundCount    int32 // 回合数，初始值为 - 1
+	history       *History
+	// world is the latest round, nil before the first state.
+	world *World
+	// planning is the strategy at work on the latest round, or on an
+	// earlier one it overran.
+	planning *planning
+
+	recorder *replay.Recorder
+}
+
+// NewSession returns the session of a new match.
+func NewSession(config Config) (*Session, error) {
+	strategy, err := New(config.Strategy)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{config: config, roundCount: -1, strategy: strategy, rnd: rand.New(rand.NewSource(time.Now().UnixNano()))}, nil
+}
+
+// Close ends the session.
+func (s *Session) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.planning != nil {
+		s.planning.cancel()
+	}
+	s.recorder.Close()
+}
+
+// UploadParamters 接收初始参数,把参数存储到本地
+func (s *Session) UploadParamters(arguments *player.Args_) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gameArguments = *arguments
+	s.recorder.Args(arguments)
+	return nil
+}
+
+// UploadMap 接收二维地图，存储地图到本地
+func (s *Session) UploadMap(gamemap [][]int32) error {
+	infof("New match on a %dx%d map, strategy %s", len(gamemap), len(gamemap), s.config.Strategy)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.startReplay(gamemap)
+	s.gameMap = gamemap
+	s.fields = NewFields(gamemap)
+	return nil
+}
+
+// AssignTanks 接收己方坦克list，保存到本地
+func (s *Session) AssignTanks(tanks []int32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.myTankList = tanks
+	s.recorder.Tanks(s.config.Name, tanks)
+	return nil
+}
+
+// LatestState 获取最新的状态，并马上开始计算这一回合的指令
+func (s *Session) LatestState(state *player.GameState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.roundCount++
+	s.recorder.State(int(s.roundCount), state)
+	if s.history == nil {
+		s.history = newHistory(int(s.gameArguments.MaxRound))
+	}
+	s.history = s.history.add(state)
+	s.world = &World{
+		Map:     s.gameMap,
+		Fields:  s.fields,
+		Args:    s.gameArguments,
+		Tanks:   s.myTankList,
+		State:   state,
+		Round:   int(s.roundCount),
+		History: s.history,
+		Rand:    s.rnd,
+	}
+	s.startPlanning(s.world)
+	return nil
+}
+
+// GetNewOrders 给己方坦克下达指令
+func (s *Session) GetNewOrders() ([]*player.Order, error) {
+	s.mu.Lock()
+	w, p, recorder := s.world, s.planning, s.recorder
+	s.mu.Unlock()
+	if w == nil {
+		return []*player.Order{}, nil
+	}
+	orders := collect(w, p)
+	debugf("Round %d: %v", w.Round, orders)
+	recorder.Orders(w.Round, s.config.Name, orders)
+	return orders, nil
+}
+
+// replays counts the replays started by the process.
+var replays int64
+
+// startReplay closes the replay of the last match and starts a new one in
+// the replay directory. The caller holds mu.
+func (s *Session) startReplay(gamemap [][]int32) {
+	s.recorder.Close()
+	s.recorder = nil
+	if s.config.ReplayDir == "" {
+		return
+	}
+	// matches starting in one second get their own file all the same
+	n := atomic.AddInt64(&replays, 1)
+	name := fmt.Sprintf("replay-%s-%s-%d.jsonl", time.Now().Format("20060102-150405"), strings.Replace(s.config.Name, ":", "-", -1), n)
+	r, err := replay.Create(filepath.Join(s.config.ReplayDir, name))
+	if err != nil {
+		errorf("%v", err)
+		return
+	}
+	s.recorder = r
+	s.recorder.Map(gamemap)
+}
+
+// PlayerService is the thrift handler of one connection. It delegates every
+// call to the session of the current match, a new match starts with
+// UploadMap.
+type PlayerService struct {
+	config Config
+
+	mu      sync.Mutex
+	session *Session
+	// seed seeds the strategy of every match when it is not 0.
+	seed int64
+}
+
+// NewPlayerService returns a handler with an empty session. It fails if the
+// strategy of the config is unknown.
+func NewPlayerService(config Config) (*PlayerService, error) {
+	session, err := NewSession(config)
+	if err != nil {
+		return nil, err
+	}
+	return &PlayerService{config: config, session: session}, nil
+}
+
+// Seed makes the strategy draw its random numbers from the seed from the
+// next match on, so that an in-process match plays the same way every time.
+// It implements engine.Seeder.
+func (p *PlayerService) Seed(seed int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.seed = seed
+}
+
+// Ping is a handler for thrift service.
+func (p *PlayerService) Ping() (bool, error) {
+	return true, nil
+}
+
+// UploadParamters is a handler for thrift service.
+func (p *PlayerService) UploadParamters(arguments *player.Args_) error {
+	return p.current().UploadParamters(arguments)
+}
+
+// UploadMap is a handler for thrift service.
+func (p *PlayerService) UploadMap(gamemap [][]int32) error {
+	// 新的一局从上传地图开始
+	session, err := NewSession(p.config)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	if p.seed != 0 {
+		session.rnd = rand.New(rand.NewSource(p.seed))
+	}
+	old := p.session
+	p.session = session
+	p.mu.Unlock()
+	old.Close()
+	return session.UploadMap(gamemap)
+}
+
+// AssignTanks is a handler for thrift service.
+func (p *PlayerService) AssignTanks(tanks []int32) error {
+	return p.current().AssignTanks(tanks)
+}
+
+// LatestState is a handler for thrift service.
+func (p *PlayerService) LatestState(state *player.GameState) error {
+	return p.current().LatestState(state)
+}
+
+// GetNewOrders is a handler for thrift service.
+func (p *PlayerService) GetNewOrders() ([]*player.Order, error) {
+	return p.current().GetNewOrders()
+}
+
+// Close ends the current session.
+func (p *PlayerService) Close() {
+	p.current().Close()
+}
+
+func (p *PlayerService) current() *Session {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.session
+}
+
+// processorFactory gives every connection its own PlayerService, so the
+// engine reconnecting or two engines at once do not mix their matches.
+type processorFactory struct {
+	config Config
+}
+
+// NewProcessorFactory returns the processor factory of a thrift server
+// playing with config. It fails if the strategy of the config is unknown.
+func NewProcessorFactory(config Config) (thrift.TProcessorFactory, error) {
+	if _, err := New(config.Strategy); err != nil {
+		return nil, err
+	}
+	return processorFactory{config}, nil
+}
+
+func (f processorFactory) GetProcessor(trans thrift.TTransport) thrift.TProcessor {
+	// the config was checked by NewProcessorFactory
+	p, _ := NewPlayerService(f.config)
+	return connProcessor{player.NewPlayerServiceProcessor(p), p}
+}
+
+// connProcessor is the processor of one connection. The server drops the
+// connection on the first call failing, which ends its match.
+type connProcessor struct {
+	thrift.TProcessor
+	service *PlayerService
+}
+
+func (p connProcessor) Process(in, out thrift.TProtocol) (bool, thrift.TException) {
+	ok, err := p.TProcessor.Process(in, out)
+	if err != nil || !ok {
+		p.service.Close()
+	}
+	return ok, err
+}
@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+// TestPlayerServiceConcurrent calls the handlers from many goroutines at
+// once and in any order, the way engines reconnecting or misbehaving may.
+// Run it with -race. The service ends a match on Close, the thrift service
+// has no call for the end of a game.
+func TestPlayerServiceConcurrent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	SetLogLevel("error")
+	defer SetLogLevel("info")
+
+	for _, strategy := range []string{Default, "team"} {
+		p, err := NewPlayerService(Config{Strategy: strategy, Name: "test", ReplayDir: dir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := openMap(13)
+		calls := []func(round int) error{
+			func(int) error { return p.UploadMap(m) },
+			func(int) error {
+				return p.UploadParamters(&player.Args_{TankSpeed: 1, ShellSpeed: 2, TankHP: 1, MaxRound: 50, RoundTimeoutInMs: 200})
+			},
+			func(int) error { return p.AssignTanks([]int32{1, 2, 3, 4}) },
+			func(round int) error { return p.LatestState(sessionState(round)) },
+			func(int) error {
+				orders, err := p.GetNewOrders()
+				if err == nil && orders == nil {
+					t.Error("GetNewOrders gave nil orders")
+				}
+				return err
+			},
+			func(int) error { p.Close(); return nil },
+		}
+
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func(seed int64) {
+				defer wg.Done()
+				rnd := rand.New(rand.NewSource(seed))
+				for round := 0; round < 30; round++ {
+					for _, i := range rnd.Perm(len(calls)) {
+						if err := calls[i](round); err != nil {
+							t.Error(err)
+						}
+					}
+				}
+			}(int64(g))
+		}
+		wg.Wait()
+		p.Close()
+	}
+}
+
+func TestReplayNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replays")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// two matches of one name starting at once
+	for i := 0; i < 2; i++ {
+		p, err := NewPlayerService(Config{Strategy: Default, Name: "test", ReplayDir: dir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.UploadMap(openMap(13)); err != nil {
+			t.Fatal(err)
+		}
+		p.Close()
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Errorf("two matches left %d replays", len(files))
+	}
+}
+
+// sessionState returns a state of the round with my four tanks and one
+// enemy on a board of 13 cells a side.
+func sessionState(round int) *player.GameState {
+	state := &player.GameState{}
+	for i, pos := range [][2]int32{{1, 1}, {1, 2}, {2, 1}, {2, 2}, {10, 10}} {
+		state.Tanks = append(state.Tanks, &player.Tank{
+			ID:  int32(i + 1),
+			Pos: &player.Position{X: pos[0] + int32(round%2), Y: pos[1]},
+			Dir: player.Direction_DOWN,
+			Hp:  1,
+		})
+	}
+	state.Shells = []*player.Shell{{ID: 100, Pos: &player.Position{X: 5, Y: int32(1 + round%10)}, Dir: player.Direction_RIGHT}}
+	return state
+}
@@ -0,0 +1,143 @@
+package bot
+
+import "github.com/eleme/purchaseMeiTuan/player"
+
+// maxShotRounds is how many rounds ahead the shells are followed, a shell
+// crosses the largest board in fewer.
+const maxShotRounds = 64
+
+// Shots are the cells the shells in sight hit in the coming rounds. Rounds
+// count from 1, the round the orders of the world are played in.
+//
+// The engine resolves a round in this order: every shell flies ShellSpeed
+// cells one at a time, then the tanks fire, turn and move. So a tank is hit
+// in round r when a shell flies over the cell it stands on in round r, or
+// when it moves onto the cell a shell stopped on in round r. A shell stops
+// at the first barrier or tank it flies into. The tanks are where the world
+// has them for the first round only: after that they may have moved, and
+// the shells are followed on past them.
+type Shots struct {
+	size int
+	// flies[x*size+y] has bit r-1 set if a shell flies over x, y in round r.
+	flies []uint64
+	// stops[x*size+y] has bit r-1 set if a shell ends round r on x, y.
+	stops []uint64
+	// last is the last round a shell flies in.
+	last int
+	// shells are the shells in sight and paths the cells each flies over.
+	shells []*player.Shell
+	paths  [][]shotCell
+}
+
+type shotCell struct {
+	cell, round int
+}
+
+// PredictShots follows every shell of the world until it is destroyed.
+func PredictShots(w *World) *Shots {
+	size := w.Size()
+	s := &Shots{
+		size:  size,
+		flies: make([]uint64, size*size),
+		stops: make([]uint64, size*size),
+	}
+	tanks := map[int]bool{}
+	for _, t := range w.State.Tanks {
+		tanks[s.index(t.Pos)] = true
+	}
+	for _, shell := range w.State.Shells {
+		s.shells = append(s.shells, shell)
+		s.paths = append(s.paths, s.follow(w, shell, tanks))
+	}
+	return s
+}
+
+// follow marks the cells the shell flies over and returns them.
+func (s *Shots) follow(w *World, shell *player.Shell, tanks map[int]bool) []shotCell {
+	path := []shotCell{}
+	pos := shell.Pos
+	for round := 1; round <= maxShotRounds; round++ {
+		for i := int32(0); i < w.Args.ShellSpeed; i++ {
+			pos = step(pos, shell.Dir)
+			if w.Cell(int(pos.X), int(pos.Y)) == 1 {
+				return path
+			}
+			c := s.index(pos)
+			s.flies[c] |= 1 << uint(round-1)
+			if round > s.last {
+				s.last = round
+			}
+			path = append(path, shotCell{c, round})
+			if round == 1 && tanks[c] {
+				return path
+			}
+		}
+		// a shell which does not move may be off the board sent
+		if s.on(pos) {
+			s.stops[s.index(pos)] |= 1 << uint(round-1)
+		}
+	}
+	return path
+}
+
+// Hit reports whether a tank standing on pos in the round is hit.
+func (s *Shots) Hit(pos *player.Position, round int) bool {
+	return s.has(s.flies, pos, round)
+}
+
+// Lands reports whether a tank moving onto pos in the round is hit, a shell
+// stopped there at the end of the flight.
+func (s *Shots) Lands(pos *player.Position, round int) bool {
+	return s.has(s.stops, pos, round)
+}
+
+// Rounds returns the rounds a tank standing on pos is hit in, in order.
+func (s *Shots) Rounds(pos *player.Position) []int {
+	rounds := []int{}
+	for round := 1; round <= maxShotRounds; round++ {
+		if s.Hit(pos, round) {
+			rounds = append(rounds, round)
+		}
+	}
+	return rounds
+}
+
+// Horizon returns the last round a shell flies in, 0 if there is none: no
+// tank is hit by the shells in sight after it.
+func (s *Shots) Horizon() int {
+	return s.last
+}
+
+// Threats returns the shells hitting a tank standing on pos within the
+// given number of rounds, in the order of the state.
+func (s *Shots) Threats(pos *player.Position, rounds int) []*player.Shell {
+	threats := []*player.Shell{}
+	if !s.on(pos) {
+		return threats
+	}
+	c := s.index(pos)
+	for i, path := range s.paths {
+		for _, sc := range path {
+			if sc.cell == c && sc.round <= rounds {
+				threats = append(threats, s.shells[i])
+				break
+			}
+		}
+	}
+	return threats
+}
+
+func (s *Shots) has(rounds []uint64, pos *player.Position, round int) bool {
+	if round < 1 || round > maxShotRounds || !s.on(pos) {
+		return false
+	}
+	return rounds[s.index(pos)]&(1<<uint(round-1)) != 0
+}
+
+func (s *Shots) on(pos *player.Position) bool {
+	return pos.X >= 0 && int(pos.X) < s.size && pos.Y >= 0 && int(pos.Y) < s.size
+}
+
+func (s *Shots) index(pos *player.Position) int {
+	return int(pos.X)*s.size + int(pos.Y)
+}
@@ -0,0 +1,164 @@
+package bot
+
+import (
+	"engine"
+	"testing"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+// shotTank is a tank standing in TestPredictShots, moving one cell in the
+// first round if move is set.
+type shotTank struct {
+	x, y int
+	dir  player.Direction
+	move bool
+}
+
+// TestPredictShots follows the shells on the engine with the tanks standing
+// still after the first round. Every cell a shell ends a round on must be
+// one Lands gives, and a tank put on any cell must be hit in the round Hit
+// first gives, by a shell Threats gives.
+func TestPredictShots(t *testing.T) {
+	tests := []struct {
+		name     string
+		barriers [][2]int
+		tanks    []shotTank
+		shells   []*engine.Shell
+		speed    int
+	}{
+		{"barrier stop", [][2]int{{5, 7}}, nil,
+			[]*engine.Shell{{ID: 9, Pos: engine.Position{X: 5, Y: 2}, Dir: player.Direction_RIGHT}}, 1},
+		{"tank stop", nil, []shotTank{{5, 5, player.Direction_UP, false}},
+			[]*engine.Shell{{ID: 9, Pos: engine.Position{X: 5, Y: 3}, Dir: player.Direction_RIGHT}}, 2},
+		// the tank is in the way in round 2 only, it may have gone by then
+		{"tank gone", nil, []shotTank{{5, 7, player.Direction_UP, true}},
+			[]*engine.Shell{{ID: 9, Pos: engine.Position{X: 5, Y: 3}, Dir: player.Direction_RIGHT}}, 2},
+		{"speed 3", [][2]int{{9, 3}}, nil,
+			[]*engine.Shell{{ID: 9, Pos: engine.Position{X: 1, Y: 3}, Dir: player.Direction_DOWN}}, 3},
+		{"meeting", nil, nil, []*engine.Shell{
+			{ID: 8, Pos: engine.Position{X: 5, Y: 2}, Dir: player.Direction_RIGHT},
+			{ID: 9, Pos: engine.Position{X: 5, Y: 9}, Dir: player.Direction_LEFT},
+		}, 1},
+		{"meeting fast", nil, nil, []*engine.Shell{
+			{ID: 8, Pos: engine.Position{X: 5, Y: 2}, Dir: player.Direction_RIGHT},
+			{ID: 9, Pos: engine.Position{X: 5, Y: 8}, Dir: player.Direction_LEFT},
+			{ID: 7, Pos: engine.Position{X: 1, Y: 5}, Dir: player.Direction_DOWN},
+		}, 2},
+	}
+	const rounds = 12
+	for _, test := range tests {
+		m := openMap(12)
+		for _, b := range test.barriers {
+			m[b[0]][b[1]] = 1
+		}
+		opts := engine.Options{NoOfTanks: 1, TankSpeed: 1, ShellSpeed: test.speed, TankHP: 1, MaxRound: 100}
+		// newMachine sets the test up on the engine, with a tank on probe
+		// if it is not nil, and returns the orders of the first round.
+		newMachine := func(probe *engine.Position) (*engine.StateMachine, []*player.Order) {
+			tanks := []*engine.Tank{}
+			ids := []int32{}
+			orders := []*player.Order{}
+			for i, st := range test.tanks {
+				tank := engine.NewTank(int32(i+1), engine.Position{X: st.x, Y: st.y}, st.dir, 1)
+				tanks = append(tanks, tank)
+				ids = append(ids, tank.ID)
+				if st.move {
+					orders = append(orders, &player.Order{TankId: tank.ID, Order: engine.OrderMove, Dir: st.dir})
+				}
+			}
+			if probe != nil {
+				tanks = append(tanks, engine.NewTank(50, *probe, player.Direction_UP, 1))
+				ids = append(ids, 50)
+			}
+			sm := engine.NewStateMachine(m, tanks, []*engine.Player{{Name: "me", Tanks: ids}}, opts)
+			for _, s := range test.shells {
+				shell := *s
+				sm.Shells = append(sm.Shells, &shell)
+			}
+			return sm, orders
+		}
+
+		sm, orders := newMachine(nil)
+		ids := []int32{}
+		for _, tank := range sm.Tanks {
+			ids = append(ids, tank.ID)
+		}
+		w := &World{Map: m, Args: *opts.Args(), Tanks: ids, State: sm.ReportState("me")}
+		shots := PredictShots(w)
+
+		// the shells end every round where Lands has them
+		for round := 1; round <= rounds; round++ {
+			sm.NewOrders(orders)
+			orders = nil
+			landed := map[engine.Position]bool{}
+			for _, s := range sm.Shells {
+				landed[s.Pos] = true
+			}
+			for x := range m {
+				for y := range m[x] {
+					pos := &player.Position{X: int32(x), Y: int32(y)}
+					if lands := shots.Lands(pos, round); lands != landed[engine.Position{X: x, Y: y}] {
+						t.Errorf("%s: round %d: Lands(%d, %d) = %v, the engine has a shell there: %v", test.name, round, x, y, lands, !lands)
+					}
+				}
+			}
+		}
+
+		// a tank standing on a cell is hit in the first round Hit gives
+		taken := map[engine.Position]bool{}
+		for _, st := range test.tanks {
+			taken[engine.Position{X: st.x, Y: st.y}] = true
+			taken[engine.Position{X: st.x, Y: st.y}.Move(st.dir)] = true
+		}
+		for x := range m {
+			for y := range m[x] {
+				probe := engine.Position{X: x, Y: y}
+				if m[x][y] == 1 || taken[probe] {
+					continue
+				}
+				pos := &player.Position{X: int32(x), Y: int32(y)}
+				want := 0
+				if hits := shots.Rounds(pos); len(hits) > 0 {
+					want = hits[0]
+				}
+				sm, orders := newMachine(&probe)
+				hit := 0
+				var by int32
+				for round := 1; round <= rounds && hit == 0; round++ {
+					sm.NewOrders(orders)
+					orders = nil
+					if sm.Tank(50) == nil {
+						hit = round
+						for _, s := range sm.DestroyedShells {
+							if s.Pos == probe {
+								by = s.ID
+							}
+						}
+					}
+				}
+				if hit != want {
+					t.Errorf("%s: a tank on %d, %d is hit in round %d, Hit first gives %d", test.name, x, y, hit, want)
+					continue
+				}
+				threats := shots.Threats(pos, maxShotRounds)
+				if hit == 0 {
+					if len(threats) > 0 {
+						t.Errorf("%s: %d, %d is threatened by %d shells, no shell hits it", test.name, x, y, len(threats))
+					}
+					continue
+				}
+				if early := shots.Threats(pos, hit-1); len(early) > 0 {
+					t.Errorf("%s: %d, %d is threatened before round %d", test.name, x, y, hit)
+				}
+				found := false
+				for _, s := range shots.Threats(pos, hit) {
+					found = found || s.ID == by
+				}
+				if !found {
+					t.Errorf("%s: shell %d hits %d, %d in round %d, not in the threats", test.name, by, x, y, hit)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package bot
+
+import "github.com/eleme/purchaseMeiTuan/player"
+
+// PlanRoutes routes my tanks to their dests together, so that no two of
+// them are ever on one cell in one round, nor swap cells. The tanks are
+// routed one after the other with Route, each keeping off the cells the
+// ones before it hold in every round of their routes: a tank holds the
+// cells it is on or passes in a round, and its dest for good once there.
+// A tank is only done on its dest if no tank before it comes by later, and
+// the tanks still to route are obstacles where they stand.
+//
+// routes[i] are the orders of tanks[i], a nil dest keeps the tank on its
+// cell, stepping aside while a shell comes by. A tank which cannot reach
+// its dest gets no orders and holds its cell for good.
+func PlanRoutes(w *World, shots *Shots, tanks []*player.Tank, dests []*player.Position) (routes [][]*player.Order) {
+	res := newReservations()
+	for i, tank := range tanks {
+		dest := dests[i]
+		if dest == nil {
+			dest = tank.Pos
+		}
+		var orders []*player.Order
+		cells := []*player.Position{tank.Pos}
+		if o, c, found := route(w, shots, tank, dest, res); found {
+			orders, cells = o, c
+		}
+		res.hold(tank.ID, cells)
+		routes = append(routes, orders)
+	}
+	return routes
+}
+
+// reservations are the cells my routed tanks hold, by round. A nil
+// reservations holds nothing.
+type reservations struct {
+	tanks map[int32]bool
+	// rounds[{x, y, r}] is true if a tank is on x, y in round r.
+	rounds map[[3]int]bool
+	// last[{x, y}] is the last round x, y is held in.
+	last map[[2]int]int
+	// since[{x, y}] is the round from which a tank stays on x, y.
+	since map[[2]int]int
+	// end is the last round held, but for good.
+	end int
+}
+
+func newReservations() *reservations {
+	return &reservations{
+		tanks:  map[int32]bool{},
+		rounds: map[[3]int]bool{},
+		last:   map[[2]int]int{},
+		since:  map[[2]int]int{},
+	}
+}
+
+// hold reserves the cells of the tank, which is on cells[i] after round i:
+// in a round the cell it starts on and the cells it moves over, and its
+// last cell from then on.
+func (res *reservations) hold(id int32, cells []*player.Position) {
+	res.tanks[id] = true
+	for i := 1; i < len(cells); i++ {
+		pos := cells[i-1]
+		res.add(pos, i)
+		for pos.X != cells[i].X || pos.Y != cells[i].Y {
+			pos = step(pos, Dir(pos, cells[i]))
+			res.add(pos, i)
+		}
+	}
+	last := cells[len(cells)-1]
+	res.since[[2]int{int(last.X), int(last.Y)}] = len(cells)
+	if len(cells) > res.end {
+		res.end = len(cells)
+	}
+}
+
+func (res *reservations) add(pos *player.Position, round int) {
+	x, y := int(pos.X), int(pos.Y)
+	res.rounds[[3]int{x, y, round}] = true
+	if round > res.last[[2]int{x, y}] {
+		res.last[[2]int{x, y}] = round
+	}
+	if round > res.end {
+		res.end = round
+	}
+}
+
+// has reports whether the tank has its cells reserved.
+func (res *reservations) has(id int32) bool {
+	return res != nil && res.tanks[id]
+}
+
+// held reports whether a tank is on x, y in the round.
+func (res *reservations) held(x, y, round int) bool {
+	if res == nil {
+		return false
+	}
+	if since, ok := res.since[[2]int{x, y}]; ok && round >= since {
+		return true
+	}
+	return res.rounds[[3]int{x, y, round}]
+}
+
+// free reports whether no tank is on x, y in the round or after.
+func (res *reservations) free(x, y, round int) bool {
+	if res == nil {
+		return true
+	}
+	if _, ok := res.since[[2]int{x, y}]; ok {
+		return false
+	}
+	return res.last[[2]int{x, y}] < round
+}
+
+// horizon returns the last round after which the cells held do not change.
+func (res *reservations) horizon() int {
+	if res == nil {
+		return 0
+	}
+	return res.end
+}
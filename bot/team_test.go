@@ -0,0 +1,141 @@
+package bot
+
+import (
+	"engine"
+	"testing"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+func TestPlanRoutesHold(t *testing.T) {
+	tests := []struct {
+		name   string
+		shells []*player.Shell
+		// moves is whether the tank must leave its cell.
+		moves bool
+	}{
+		{"quiet", nil, false},
+		// the shell hits 5,5 in round 3
+		{"shot later", []*player.Shell{{ID: 9, Pos: &player.Position{X: 5, Y: 8}, Dir: player.Direction_LEFT}}, true},
+	}
+	for _, test := range tests {
+		tank := &player.Tank{ID: 1, Pos: &player.Position{X: 5, Y: 5}, Dir: player.Direction_UP, Hp: 1}
+		w := testWorld(openMap(10), []*player.Tank{tank}, test.shells)
+		routes := PlanRoutes(w, PredictShots(w), []*player.Tank{tank}, []*player.Position{nil})
+		if moves := len(routes[0]) > 0; moves != test.moves {
+			t.Errorf("%s: the tank with no dest gets %v, want orders %v", test.name, routes[0], test.moves)
+		}
+	}
+}
+
+// teamTank is one of my tanks in TestPlanRoutesEngine, with its dest.
+type teamTank struct {
+	x, y   int
+	dir    player.Direction
+	dx, dy int
+}
+
+// TestPlanRoutesEngine plays the routes on the engine, both the routes of
+// the first round to their end and routes planned again every round the way
+// the team strategy does. No move may be rolled back, which the engine does
+// to tanks running into each other or swapping cells.
+func TestPlanRoutesEngine(t *testing.T) {
+	tests := []struct {
+		name  string
+		tanks []teamTank
+		// reach is how many tanks end on their dests planning every round.
+		reach int
+	}{
+		{"crossing", []teamTank{
+			{2, 5, player.Direction_DOWN, 8, 5},
+			{5, 2, player.Direction_RIGHT, 5, 8},
+		}, 2},
+		{"head on", []teamTank{
+			{5, 3, player.Direction_RIGHT, 5, 6},
+			{5, 6, player.Direction_LEFT, 5, 3},
+		}, 2},
+		{"corners", []teamTank{
+			{3, 3, player.Direction_DOWN, 6, 6},
+			{6, 6, player.Direction_UP, 3, 3},
+			{3, 6, player.Direction_DOWN, 6, 3},
+			{6, 3, player.Direction_UP, 3, 6},
+		}, 4},
+		{"in line", []teamTank{
+			{5, 2, player.Direction_RIGHT, 5, 7},
+			{5, 3, player.Direction_RIGHT, 5, 2},
+			{5, 4, player.Direction_LEFT, 5, 3},
+		}, 3},
+		{"shared dest", []teamTank{
+			{2, 2, player.Direction_DOWN, 5, 5},
+			{8, 8, player.Direction_UP, 5, 5},
+			{2, 8, player.Direction_LEFT, 5, 5},
+		}, 1},
+	}
+	for _, test := range tests {
+		for _, replan := range []bool{false, true} {
+			name := test.name
+			if replan {
+				name += " replanned"
+			}
+			m := openMap(10)
+			tanks := []*engine.Tank{}
+			ids := []int32{}
+			dests := []*player.Position{}
+			for i, tt := range test.tanks {
+				tanks = append(tanks, engine.NewTank(int32(i+1), engine.Position{X: tt.x, Y: tt.y}, tt.dir, 1))
+				ids = append(ids, int32(i+1))
+				dests = append(dests, &player.Position{X: int32(tt.dx), Y: int32(tt.dy)})
+			}
+			opts := engine.Options{NoOfTanks: len(tanks), TankSpeed: 1, ShellSpeed: 2, TankHP: 1, MaxRound: 100}
+			sm := engine.NewStateMachine(m, tanks, []*engine.Player{{Name: "me", Tanks: ids}}, opts)
+
+			var routes [][]*player.Order
+			for round := 0; round < 30; round++ {
+				if round == 0 || replan {
+					w := &World{Map: m, Args: *opts.Args(), Tanks: ids, State: sm.ReportState("me")}
+					routes = PlanRoutes(w, PredictShots(w), w.MyTanks(), dests)
+				}
+				step := round
+				if replan {
+					step = 0
+				}
+				orders := []*player.Order{}
+				want := map[*engine.Tank]engine.Position{}
+				for i, route := range routes {
+					want[tanks[i]] = tanks[i].Pos
+					if step >= len(route) {
+						continue
+					}
+					orders = append(orders, route[step])
+					if route[step].Order == engine.OrderMove {
+						want[tanks[i]] = tanks[i].Pos.Move(tanks[i].Dir)
+					}
+				}
+				if len(orders) == 0 {
+					break
+				}
+				sm.NewOrders(orders)
+				cells := map[engine.Position]int32{}
+				for _, tank := range tanks {
+					if tank.Pos != want[tank] {
+						t.Errorf("%s: round %d: tank %d rolled back to %v, want %v", name, round, tank.ID, tank.Pos, want[tank])
+					}
+					if other, ok := cells[tank.Pos]; ok {
+						t.Errorf("%s: round %d: tanks %d and %d on %v", name, round, other, tank.ID, tank.Pos)
+					}
+					cells[tank.Pos] = tank.ID
+				}
+			}
+
+			reached := 0
+			for i, tank := range tanks {
+				if tank.Pos == (engine.Position{X: int(dests[i].X), Y: int(dests[i].Y)}) {
+					reached++
+				}
+			}
+			if replan && reached != test.reach {
+				t.Errorf("%s: %d tanks reached their dests, want %d", name, reached, test.reach)
+			}
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package bot
+
+import (
+	"sort"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+// Tracker keeps, for every enemy tank seen once, the cells it may be in. A
+// tank out of sight is in the forest, so from the cell it was last seen in
+// its cells spread by TankSpeed every round and are cut down to the forest
+// cells. A shell coming out of the forest gives its tank away, the tank is
+// on the forest cells behind it. A tank with no cell left is taken for
+// destroyed, and so is a tank in sight which a shell in sight flies over
+// in the coming round and which is gone then: the shells fly before the
+// tanks move, it had no way into the forest.
+type Tracker struct {
+	size  int
+	round int
+	// cells[id][x*size+y] is true if the tank may be at x, y.
+	cells map[int32][]bool
+	// shells are the enemy shells of the last round, by tank.
+	shells map[int32]*player.Shell
+	// last is the state of the last round.
+	last *player.GameState
+}
+
+// NewTracker returns a tracker knowing nothing yet.
+func NewTracker() *Tracker {
+	return &Tracker{round: -1, cells: map[int32][]bool{}, shells: map[int32]*player.Shell{}}
+}
+
+// Update brings the tracker to the round of w. It goes through every round
+// it missed in w.History, if w has one.
+func (t *Tracker) Update(w *World) {
+	t.size = w.Size()
+	from := t.round + 1
+	if w.History == nil {
+		from = w.Round
+	}
+	for round := from; round <= w.Round; round++ {
+		state := w.State
+		if round != w.Round {
+			state = w.History.State(round)
+		}
+		if state != nil {
+			t.step(w, state)
+		}
+	}
+	t.round = w.Round
+}
+
+func (t *Tracker) step(w *World, state *player.GameState) {
+	seen := map[int32]bool{}
+	for _, tank := range state.Tanks {
+		if w.Mine(tank.ID) {
+			continue
+		}
+		seen[tank.ID] = true
+		cells := make([]bool, t.size*t.size)
+		cells[t.index(tank.Pos)] = true
+		t.cells[tank.ID] = cells
+	}
+
+	for id, cells := range t.cells {
+		if seen[id] {
+			continue
+		}
+		if t.shot(w, id) {
+			delete(t.cells, id)
+			continue
+		}
+		cells = t.spread(w, cells, int(w.Args.TankSpeed))
+		t.hide(w, state, cells)
+		t.cells[id] = cells
+	}
+
+	shells := map[int32]*player.Shell{}
+	for _, s := range state.Shells {
+		if w.Mine(s.ID) {
+			continue
+		}
+		shells[s.ID] = s
+		if last := t.shells[s.ID]; last != nil && ahead(last, s) {
+			continue
+		}
+		if cells, ok := t.cells[s.ID]; ok && !seen[s.ID] {
+			t.behind(w, cells, s)
+		}
+	}
+	t.shells = shells
+	t.last = state
+
+	for id, cells := range t.cells {
+		if count(cells) == 0 {
+			delete(t.cells, id)
+		}
+	}
+}
+
+// shot reports whether the tank, in sight in the last round, was destroyed
+// by a shell in sight flying over it before it could move.
+func (t *Tracker) shot(w *World, id int32) bool {
+	if t.last == nil {
+		return false
+	}
+	var tank *player.Tank
+	tanks := map[int]bool{}
+	for _, tk := range t.last.Tanks {
+		tanks[t.index(tk.Pos)] = true
+		if tk.ID == id {
+			tank = tk
+		}
+	}
+	if tank == nil || tank.Hp > 1 {
+		return false
+	}
+	for _, s := range t.last.Shells {
+		pos := s.Pos
+		for i := int32(0); i < w.Args.ShellSpeed; i++ {
+			pos = step(pos, s.Dir)
+			if w.Cell(int(pos.X), int(pos.Y)) == 1 {
+				break
+			}
+			if pos.X == tank.Pos.X && pos.Y == tank.Pos.Y {
+				return true
+			}
+			if tanks[t.index(pos)] {
+				break
+			}
+		}
+	}
+	return false
+}
+
+// spread returns the cells a tank in cells may be in after moving n steps.
+func (t *Tracker) spread(w *World, cells []bool, n int) []bool {
+	for i := 0; i < n; i++ {
+		next := append([]bool{}, cells...)
+		for c, ok := range cells {
+			if !ok {
+				continue
+			}
+			x, y := c/t.size, c%t.size
+			for _, d := range [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+				if w.Cell(x+d[0], y+d[1]) != 1 {
+					next[(x+d[0])*t.size+y+d[1]] = true
+				}
+			}
+		}
+		cells = next
+	}
+	return cells
+}
+
+// hide keeps the cells a tank out of sight may be in: forest cells without
+// a tank I see.
+func (t *Tracker) hide(w *World, state *player.GameState, cells []bool) {
+	for c := range cells {
+		if cells[c] && w.Cell(c/t.size, c%t.size) != 2 {
+			cells[c] = false
+		}
+	}
+	for _, tank := range state.Tanks {
+		cells[t.index(tank.Pos)] = false
+	}
+}
+
+// behind narrows cells down to the forest cells behind a new shell, where
+// its tank fired it from. Cells which do not fit are left alone.
+func (t *Tracker) behind(w *World, cells []bool, s *player.Shell) {
+	back := step(s.Pos, opposite(s.Dir))
+	line := make([]bool, len(cells))
+	found := false
+	for w.Cell(int(back.X), int(back.Y)) == 2 {
+		c := t.index(back)
+		line[c] = true
+		found = found || cells[c]
+		back = step(back, opposite(s.Dir))
+	}
+	if !found {
+		return
+	}
+	for c := range cells {
+		cells[c] = cells[c] && line[c]
+	}
+}
+
+// Enemies returns the enemy tanks tracked, by id.
+func (t *Tracker) Enemies() []int32 {
+	ids := []int32{}
+	for id := range t.cells {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Cells returns the cells the tank may be in, none if it is not tracked.
+func (t *Tracker) Cells(id int32) []*player.Position {
+	positions := []*player.Position{}
+	for c, ok := range t.cells[id] {
+		if ok {
+			positions = append(positions, &player.Position{X: int32(c / t.size), Y: int32(c % t.size)})
+		}
+	}
+	return positions
+}
+
+// Heat returns for every cell the expected number of enemy tanks in it,
+// each tank being anywhere in its cells with the same chance.
+func (t *Tracker) Heat() [][]float64 {
+	heat := make([][]float64, t.size)
+	for x := range heat {
+		heat[x] = make([]float64, t.size)
+	}
+	for _, cells := range t.cells {
+		p := 1 / float64(count(cells))
+		for c, ok := range cells {
+			if ok {
+				heat[c/t.size][c%t.size] += p
+			}
+		}
+	}
+	return heat
+}
+
+// Hottest returns the cell with the most heat among those out of sight,
+// ok is false when every tracked tank is in sight or none is tracked.
+func (t *Tracker) Hottest(w *World) (pos *player.Position, ok bool) {
+	best := 0.0
+	for x, row := range t.Heat() {
+		for y, h := range row {
+			if h > best && w.Cell(x, y) == 2 {
+				best, pos = h, &player.Position{X: int32(x), Y: int32(y)}
+			}
+		}
+	}
+	return pos, pos != nil
+}
+
+func (t *Tracker) index(pos *player.Position) int {
+	return int(pos.X)*t.size + int(pos.Y)
+}
+
+func count(cells []bool) int {
+	n := 0
+	for _, ok := range cells {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+func opposite(dir player.Direction) player.Direction {
+	switch dir {
+	case player.Direction_UP:
+		return player.Direction_DOWN
+	case player.Direction_DOWN:
+		return player.Direction_UP
+	case player.Direction_LEFT:
+		return player.Direction_RIGHT
+	case player.Direction_RIGHT:
+		return player.Direction_LEFT
+	}
+	return dir
+}
@@ -0,0 +1,208 @@
+package bot
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+// forestMap returns a board of 12 with a forest on 4 to 6, 4 to 6.
+func forestMap() [][]int32 {
+	m := openMap(12)
+	for x := 4; x <= 6; x++ {
+		for y := 4; y <= 6; y++ {
+			m[x][y] = 2
+		}
+	}
+	return m
+}
+
+// trackState returns a state with my tank 1 in a corner, the enemy tanks
+// and the shells.
+func trackState(enemies []*player.Tank, shells []*player.Shell) *player.GameState {
+	tanks := []*player.Tank{{ID: 1, Pos: &player.Position{X: 10, Y: 10}, Dir: player.Direction_UP, Hp: 1}}
+	return &player.GameState{Tanks: append(tanks, enemies...), Shells: shells}
+}
+
+func enemyAt(id, x, y int32) *player.Tank {
+	return &player.Tank{ID: id, Pos: &player.Position{X: x, Y: y}, Dir: player.Direction_DOWN, Hp: 1}
+}
+
+// trackWorlds returns the world of every round of the states, each with
+// the history up to it.
+func trackWorlds(m [][]int32, speed int32, states ...*player.GameState) []*World {
+	worlds := []*World{}
+	h := newHistory(0)
+	for round, state := range states {
+		h = h.add(state)
+		worlds = append(worlds, &World{
+			Map:     m,
+			Args:    player.Args_{TankSpeed: speed, ShellSpeed: 1, TankHP: 1, MaxRound: 100},
+			Tanks:   []int32{1},
+			State:   state,
+			Round:   round,
+			History: h,
+		})
+	}
+	return worlds
+}
+
+// track updates a tracker with every world in turn.
+func track(worlds []*World) *Tracker {
+	tr := NewTracker()
+	for _, w := range worlds {
+		tr.Update(w)
+	}
+	return tr
+}
+
+func cellsOf(tr *Tracker, id int32) [][2]int32 {
+	cells := [][2]int32{}
+	for _, pos := range tr.Cells(id) {
+		cells = append(cells, [2]int32{pos.X, pos.Y})
+	}
+	return cells
+}
+
+func TestTrackerSpread(t *testing.T) {
+	tests := []struct {
+		name   string
+		speed  int32
+		unseen int
+		want   [][2]int32
+	}{
+		{"one round", 1, 1, [][2]int32{{4, 5}}},
+		{"two rounds", 1, 2, [][2]int32{{4, 4}, {4, 5}, {4, 6}, {5, 5}}},
+		{"speed 2", 2, 1, [][2]int32{{4, 4}, {4, 5}, {4, 6}, {5, 5}}},
+		// the forest is all the tank can be in
+		{"whole forest", 2, 3, [][2]int32{{4, 4}, {4, 5}, {4, 6}, {5, 4}, {5, 5}, {5, 6}, {6, 4}, {6, 5}, {6, 6}}},
+	}
+	for _, test := range tests {
+		states := []*player.GameState{trackState([]*player.Tank{enemyAt(2, 3, 5)}, nil)}
+		for i := 0; i < test.unseen; i++ {
+			states = append(states, trackState(nil, nil))
+		}
+		tr := track(trackWorlds(forestMap(), test.speed, states...))
+		if got := cellsOf(tr, 2); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: cells %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestTrackerBehind(t *testing.T) {
+	tests := []struct {
+		name  string
+		shell *player.Shell
+		want  [][2]int32
+	}{
+		{"column", &player.Shell{ID: 2, Pos: &player.Position{X: 7, Y: 4}, Dir: player.Direction_DOWN}, [][2]int32{{4, 4}}},
+		{"row", &player.Shell{ID: 2, Pos: &player.Position{X: 5, Y: 7}, Dir: player.Direction_RIGHT}, [][2]int32{{5, 5}}},
+		{"middle", &player.Shell{ID: 2, Pos: &player.Position{X: 7, Y: 5}, Dir: player.Direction_DOWN}, [][2]int32{{4, 5}, {5, 5}}},
+		// the tank cannot have fired it, the shell tells nothing
+		{"elsewhere", &player.Shell{ID: 2, Pos: &player.Position{X: 3, Y: 7}, Dir: player.Direction_RIGHT}, [][2]int32{{4, 4}, {4, 5}, {4, 6}, {5, 5}}},
+		{"other tank", &player.Shell{ID: 3, Pos: &player.Position{X: 7, Y: 4}, Dir: player.Direction_DOWN}, [][2]int32{{4, 4}, {4, 5}, {4, 6}, {5, 5}}},
+	}
+	for _, test := range tests {
+		tr := track(trackWorlds(forestMap(), 1,
+			trackState([]*player.Tank{enemyAt(2, 3, 5)}, nil),
+			trackState(nil, nil),
+			trackState(nil, []*player.Shell{test.shell})))
+		if got := cellsOf(tr, 2); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: cells %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestTrackerDrop(t *testing.T) {
+	hp2 := enemyAt(2, 3, 5)
+	hp2.Hp = 2
+	tests := []struct {
+		name   string
+		enemy  *player.Tank
+		shells []*player.Shell
+		// tracked is whether the tank is still tracked when gone.
+		tracked bool
+	}{
+		{"into the forest", enemyAt(2, 3, 5), nil, true},
+		// no forest within reach, the tank has no cell left
+		{"open ground", enemyAt(2, 8, 2), nil, false},
+		// my shell flies over the tank before it can move
+		{"shot", enemyAt(2, 3, 5), []*player.Shell{{ID: 1, Pos: &player.Position{X: 3, Y: 6}, Dir: player.Direction_LEFT}}, false},
+		{"shell short", enemyAt(2, 3, 5), []*player.Shell{{ID: 1, Pos: &player.Position{X: 3, Y: 7}, Dir: player.Direction_LEFT}}, true},
+		{"shell stopped", enemyAt(2, 3, 5), []*player.Shell{{ID: 1, Pos: &player.Position{X: 1, Y: 5}, Dir: player.Direction_DOWN}}, true},
+		{"hit but alive", hp2, []*player.Shell{{ID: 1, Pos: &player.Position{X: 3, Y: 6}, Dir: player.Direction_LEFT}}, true},
+	}
+	for _, test := range tests {
+		first := trackState([]*player.Tank{test.enemy}, test.shells)
+		if test.name == "shell stopped" {
+			// my tank is between the shell and the enemy
+			first.Tanks[0].Pos = &player.Position{X: 2, Y: 5}
+		}
+		tr := track(trackWorlds(forestMap(), 1, first, trackState(nil, nil)))
+		if tracked := len(tr.Enemies()) == 1; tracked != test.tracked {
+			t.Errorf("%s: tracked %v, want %v", test.name, tracked, test.tracked)
+		}
+	}
+}
+
+func TestTrackerHeat(t *testing.T) {
+	tr := track(trackWorlds(forestMap(), 1,
+		trackState([]*player.Tank{enemyAt(2, 3, 5), enemyAt(3, 5, 7)}, nil),
+		trackState([]*player.Tank{enemyAt(3, 5, 7)}, nil),
+		trackState(nil, nil),
+		trackState([]*player.Tank{enemyAt(4, 8, 8)}, nil)))
+	if got := tr.Enemies(); !reflect.DeepEqual(got, []int32{2, 3, 4}) {
+		t.Fatalf("tracking %v, want [2 3 4]", got)
+	}
+	sum := 0.0
+	for _, row := range tr.Heat() {
+		for _, h := range row {
+			sum += h
+		}
+	}
+	if math.Abs(sum-3) > 1e-9 {
+		t.Errorf("heat sums to %v, want 3", sum)
+	}
+	// 8, 8 is in sight, the hottest cells out of sight are those both
+	// hidden tanks may be in, the first of them is taken
+	w := trackWorlds(forestMap(), 1, trackState([]*player.Tank{enemyAt(4, 8, 8)}, nil))[0]
+	if pos, ok := tr.Hottest(w); !ok || pos.X != 4 || pos.Y != 6 {
+		t.Errorf("hottest %v %v, want 4, 6", pos, ok)
+	}
+}
+
+func TestTrackerCatchUp(t *testing.T) {
+	worlds := trackWorlds(forestMap(), 1,
+		trackState([]*player.Tank{enemyAt(2, 3, 5)}, nil),
+		trackState(nil, nil),
+		trackState(nil, nil),
+		trackState(nil, []*player.Shell{{ID: 2, Pos: &player.Position{X: 7, Y: 4}, Dir: player.Direction_DOWN}}))
+	want := cellsOf(track(worlds), 2)
+	if !reflect.DeepEqual(want, [][2]int32{{4, 4}, {5, 4}}) {
+		t.Fatalf("round by round: cells %v, want [[4 4] [5 4]]", want)
+	}
+
+	// the rounds missed are played from the history
+	tr := NewTracker()
+	tr.Update(worlds[0])
+	tr.Update(worlds[3])
+	if got := cellsOf(tr, 2); !reflect.DeepEqual(got, want) {
+		t.Errorf("catching up: cells %v, want %v", got, want)
+	}
+	tr = NewTracker()
+	tr.Update(worlds[3])
+	if got := cellsOf(tr, 2); !reflect.DeepEqual(got, want) {
+		t.Errorf("from the start: cells %v, want %v", got, want)
+	}
+
+	// without a history only the round of the world is known
+	last := *worlds[3]
+	last.History = nil
+	tr = NewTracker()
+	tr.Update(&last)
+	if got := tr.Enemies(); len(got) != 0 {
+		t.Errorf("without history: tracking %v, want none", got)
+	}
+}
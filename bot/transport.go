@@ -0,0 +1,156 @@
+package bot
+
+import (
+	"bufio"
+	"errors"
+	"sync"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+// bufferSize is the buffer of the buffered transport.
+const bufferSize = 8192
+
+// The first byte of a message of each protocol. A framed message starts with
+// its length instead, which is far below 0x80000000 for our messages.
+var protocolBytes = map[byte]string{
+	0x80: "binary",
+	0x82: "compact",
+	'[':  "json",
+}
+
+// autoTransportFactory gives every connection the framed or the buffered
+// transport, whichever the caller speaks. It looks at the first byte the
+// caller sends: a message of any protocol starts with a byte of
+// protocolBytes, a frame with the high byte of its length.
+type autoTransportFactory struct {
+	// protocol is the protocol of the server, a caller speaking another one
+	// is logged.
+	protocol string
+
+	mu sync.Mutex
+	// conns holds the transport of a connection until the server asked for
+	// both its input and its output transport, they must be the same.
+	conns map[thrift.TTransport]*autoTransport
+}
+
+func newAutoTransportFactory(protocol string) *autoTransportFactory {
+	return &autoTransportFactory{protocol: protocol, conns: map[thrift.TTransport]*autoTransport{}}
+}
+
+func (f *autoTransportFactory) GetTransport(trans thrift.TTransport) thrift.TTransport {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if t, ok := f.conns[trans]; ok {
+		delete(f.conns, trans)
+		return t
+	}
+	t := &autoTransport{raw: trans, factory: f}
+	f.conns[trans] = t
+	return t
+}
+
+func (f *autoTransportFactory) forget(trans thrift.TTransport) {
+	f.mu.Lock()
+	delete(f.conns, trans)
+	f.mu.Unlock()
+}
+
+// autoTransport is a connection whose transport is chosen on the first
+// read. The server reads a call before it writes anything.
+type autoTransport struct {
+	raw     thrift.TTransport
+	factory *autoTransportFactory
+	// t is the framed or buffered transport, nil until the caller spoke.
+	t thrift.TTransport
+}
+
+var errNotDetected = errors.New("transport written before the caller sent anything")
+
+func (a *autoTransport) detect() error {
+	if a.t != nil {
+		return nil
+	}
+	r := bufio.NewReader(a.raw)
+	b, err := r.Peek(1)
+	if err != nil {
+		return thrift.NewTTransportExceptionFromError(err)
+	}
+	src := &peekedTransport{TTransport: a.raw, r: r}
+	transport, protocol := "buffered", protocolBytes[b[0]]
+	if protocol != "" {
+		a.t = thrift.NewTBufferedTransport(src, bufferSize)
+	} else {
+		transport = "framed"
+		a.t = thrift.NewTFramedTransport(src)
+		if b, err := r.Peek(5); err == nil {
+			protocol = protocolBytes[b[4]]
+		}
+	}
+	debugf("Caller speaks the %s transport and the %s protocol", transport, protocol)
+	switch protocol {
+	case a.factory.protocol:
+	case "":
+		warnf("Caller speaks an unknown protocol, not %s", a.factory.protocol)
+	default:
+		warnf("Caller speaks the %s protocol, not %s", protocol, a.factory.protocol)
+	}
+	return nil
+}
+
+func (a *autoTransport) Read(p []byte) (int, error) {
+	if err := a.detect(); err != nil {
+		return 0, err
+	}
+	return a.t.Read(p)
+}
+
+func (a *autoTransport) Write(p []byte) (int, error) {
+	if a.t == nil {
+		return 0, errNotDetected
+	}
+	return a.t.Write(p)
+}
+
+func (a *autoTransport) Flush() error {
+	if a.t == nil {
+		return errNotDetected
+	}
+	return a.t.Flush()
+}
+
+func (a *autoTransport) RemainingBytes() uint64 {
+	if a.t == nil {
+		return ^uint64(0) // unknown
+	}
+	return a.t.RemainingBytes()
+}
+
+func (a *autoTransport) Open() error {
+	return a.raw.Open()
+}
+
+func (a *autoTransport) IsOpen() bool {
+	return a.raw.IsOpen()
+}
+
+// Close closes the connection, the server closes both its input and its
+// output transport so it is called twice.
+func (a *autoTransport) Close() error {
+	a.factory.forget(a.raw)
+	return a.raw.Close()
+}
+
+// peekedTransport reads a connection through the reader that peeked at it.
+type peekedTransport struct {
+	thrift.TTransport
+	r *bufio.Reader
+}
+
+func (p *peekedTransport) Read(b []byte) (int, error) {
+	return p.r.Read(b)
+}
+
+func (p *peekedTransport) RemainingBytes() uint64 {
+	return ^uint64(0) // unknown
+}
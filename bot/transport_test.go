@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+// memConn is a connection reading what the caller sent and keeping what the
+// server writes.
+type memConn struct {
+	in     *bytes.Reader
+	out    bytes.Buffer
+	closed int
+}
+
+func (c *memConn) Read(p []byte) (int, error)  { return c.in.Read(p) }
+func (c *memConn) Write(p []byte) (int, error) { return c.out.Write(p) }
+func (c *memConn) Close() error                { c.closed++; return nil }
+func (c *memConn) Flush() error                { return nil }
+func (c *memConn) RemainingBytes() uint64      { return ^uint64(0) }
+func (c *memConn) Open() error                 { return nil }
+func (c *memConn) IsOpen() bool                { return true }
+
+// pings are the first bytes of a ping call in each protocol.
+var pings = map[string][]byte{
+	"binary":  append([]byte{0x80, 0x01, 0x00, 0x01, 0, 0, 0, 4, 'p', 'i', 'n', 'g', 0, 0, 0, 1}, 0),
+	"compact": append([]byte{0x82, 0x21, 0x01, 0x04, 'p', 'i', 'n', 'g'}, 0),
+	"json":    []byte(`[1,"ping",1,1,{}]`),
+}
+
+// frame returns the message in a frame.
+func frame(msg []byte) []byte {
+	n := len(msg)
+	return append([]byte{byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}, msg...)
+}
+
+func TestAutoTransport(t *testing.T) {
+	defer quiet()()
+	reply := []byte("pong")
+	for _, protocol := range []string{"binary", "compact", "json"} {
+		for _, framed := range []bool{false, true} {
+			name := protocol + " buffered"
+			sent, want := pings[protocol], reply
+			if framed {
+				name = protocol + " framed"
+				sent, want = frame(sent), frame(reply)
+			}
+			conn := &memConn{in: bytes.NewReader(sent)}
+			f := newAutoTransportFactory("binary")
+
+			// the server asks for the input and then the output transport
+			in := f.GetTransport(conn)
+			if len(f.conns) != 1 {
+				t.Errorf("%s: %d connections kept after the input transport, want 1", name, len(f.conns))
+			}
+			out := f.GetTransport(conn)
+			if in != out {
+				t.Errorf("%s: the input and output transports differ", name)
+			}
+			if len(f.conns) != 0 {
+				t.Errorf("%s: %d connections kept after the output transport, want none", name, len(f.conns))
+			}
+			if _, err := out.Write(reply); err != errNotDetected {
+				t.Errorf("%s: writing before reading gave %v, want %v", name, err, errNotDetected)
+			}
+
+			got := make([]byte, len(pings[protocol]))
+			if _, err := io.ReadFull(in, got); err != nil {
+				t.Errorf("%s: %v", name, err)
+				continue
+			}
+			if !bytes.Equal(got, pings[protocol]) {
+				t.Errorf("%s: read %q, want %q", name, got, pings[protocol])
+			}
+			if _, ok := in.(*autoTransport).t.(*thrift.TFramedTransport); ok != framed {
+				t.Errorf("%s: framed transport chosen %v, want %v", name, ok, framed)
+			}
+
+			// the reply goes the way the call came
+			if _, err := out.Write(reply); err != nil {
+				t.Errorf("%s: %v", name, err)
+			}
+			if err := out.Flush(); err != nil {
+				t.Errorf("%s: %v", name, err)
+			}
+			if !bytes.Equal(conn.out.Bytes(), want) {
+				t.Errorf("%s: wrote %q, want %q", name, conn.out.Bytes(), want)
+			}
+
+			in.Close()
+			out.Close()
+			if conn.closed != 2 || len(f.conns) != 0 {
+				t.Errorf("%s: closed %d times with %d connections kept, want 2 and none", name, conn.closed, len(f.conns))
+			}
+		}
+	}
+}
+
+// TestAutoTransportClose closes a connection the server did not ask the
+// output transport of, it must not be kept.
+func TestAutoTransportClose(t *testing.T) {
+	f := newAutoTransportFactory("binary")
+	conn := &memConn{in: bytes.NewReader(nil)}
+	in := f.GetTransport(conn)
+	if _, err := in.Read(make([]byte, 1)); err == nil {
+		t.Error("reading a closed connection gave no error")
+	}
+	in.Close()
+	if len(f.conns) != 0 {
+		t.Errorf("%d connections kept after Close, want none", len(f.conns))
+	}
+}
@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"math/rand"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+// World is what a strategy knows of the match at one round. It is shared
+// with the server and must not be modified.
+type World struct {
+	// Map is the board sent by UploadMap, 0 empty, 1 barrier, 2 forest.
+	Map [][]int32
+	// Fields are the distance fields of Map, nil if it came without.
+	Fields *Fields
+	// Args are the parameters of the match.
+	Args player.Args_
+	// Tanks are the tanks assigned to me, some may be destroyed by now.
+	Tanks []int32
+	// State is the latest state, it holds my living tanks and what they see.
+	State *player.GameState
+	// Round counts from 0.
+	Round int
+	// History holds the states of the rounds up to this one.
+	History *History
+	// Rand is the random source of the match. Only the strategy draws from
+	// it, while it works on the round.
+	Rand *rand.Rand
+}
+
+// Size returns the width of the board, boards are square.
+func (w *World) Size() int {
+	return len(w.Map)
+}
+
+// Center returns the cell of the flag.
+func (w *World) Center() *player.Position {
+	c := int32(len(w.Map) / 2)
+	return &player.Position{X: c, Y: c}
+}
+
+// Cell returns the terrain at x, y. Cells off the board are barriers.
+func (w *World) Cell(x, y int) int32 {
+	if x < 0 || x >= len(w.Map) || y < 0 || y >= len(w.Map[x]) {
+		return 1
+	}
+	return w.Map[x][y]
+}
+
+// Tank returns the tank with the id in the state, nil if it is destroyed or
+// out of sight.
+func (w *World) Tank(id int32) *player.Tank {
+	for _, t := range w.State.Tanks {
+		if t.ID == id {
+			return t
+		}
+	}
+	return nil
+}
+
+// Mine reports whether the tank was assigned to me.
+func (w *World) Mine(id int32) bool {
+	for _, t := range w.Tanks {
+		if t == id {
+			return true
+		}
+	}
+	return false
+}
+
+// MyTanks returns my living tanks in the order they were assigned.
+func (w *World) MyTanks() []*player.Tank {
+	tanks := []*player.Tank{}
+	for _, id := range w.Tanks {
+		if t := w.Tank(id); t != nil {
+			tanks = append(tanks, t)
+		}
+	}
+	return tanks
+}
+
+// Enemies returns the enemy tanks in sight.
+func (w *World) Enemies() []*player.Tank {
+	tanks := []*player.Tank{}
+	for _, t := range w.State.Tanks {
+		if !w.Mine(t.ID) {
+			tanks = append(tanks, t)
+		}
+	}
+	return tanks
+}
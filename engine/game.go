@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+// spawnPositions are the cells of the first player's tanks, the second
+// player's tanks spawn at the rotationally symmetric cells.
+var spawnPositions = []Position{{1, 1}, {1, 2}, {2, 1}, {2, 2}, {1, 3}}
+
+// MaxTanks is the largest number of tanks per player.
+const MaxTanks = 5
+
+// Game is a whole match between two players, it adds the round counter, the
+// flag schedule and the final result to the StateMachine.
+type Game struct {
+	*StateMachine
+	// Rand is the random source of the game, a Match seeds the in-process
+	// bots from it.
+	Rand *rand.Rand
+
+	round         int
+	over          bool
+	flagGenerated bool
+	flagCount     int
+}
+
+// NewGame spawns the tanks and returns a game ready for its first round.
+// Tanks 1..NoOfTanks belong to playerA and face down, the others belong to
+// playerB and face up.
+func NewGame(gameMap [][]int32, opts Options, playerA, playerB string) (*Game, error) {
+	if opts.NoOfTanks < 1 || opts.NoOfTanks > MaxTanks {
+		return nil, fmt.Errorf("number of tanks must be between 1 and %d, got %d", MaxTanks, opts.NoOfTanks)
+	}
+	size := len(gameMap)
+	a := &Player{Name: playerA}
+	b := &Player{Name: playerB}
+	tanks := make([]*Tank, 2*opts.NoOfTanks)
+	for i := 0; i < opts.NoOfTanks; i++ {
+		pos := spawnPositions[i]
+		idA := int32(i + 1)
+		idB := int32(i + 1 + opts.NoOfTanks)
+		tanks[i] = NewTank(idA, pos, player.Direction_DOWN, opts.TankHP)
+		tanks[i+opts.NoOfTanks] = NewTank(idB, Position{size - pos.X - 1, size - pos.Y - 1}, player.Direction_UP, opts.TankHP)
+		a.Tanks = append(a.Tanks, idA)
+		b.Tanks = append(b.Tanks, idB)
+	}
+	return &Game{
+		StateMachine: NewStateMachine(gameMap, tanks, []*Player{a, b}, opts),
+		Rand:         rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// Round returns the number of rounds played, which is the round to play
+// next counting from 0.
+func (g *Game) Round() int {
+	return g.round
+}
+
+// Over reports whether the game has ended, either because a player lost all
+// of its tanks or because MaxRound rounds were played.
+func (g *Game) Over() bool {
+	return g.over || g.round >= g.Options.MaxRound
+}
+
+// FlagCount returns how many times the flag was generated.
+func (g *Game) FlagCount() int {
+	return g.flagCount
+}
+
+// Play resolves the orders of both players for the current round and
+// advances to the next one. The round a player loses its last tank counts
+// as played, no flag is generated after it.
+func (g *Game) Play(orders []*player.Order) {
+	if g.Over() {
+		return
+	}
+	g.NewOrders(orders)
+	if g.GameOver() {
+		g.over = true
+	} else {
+		g.checkGenerateFlag(g.round)
+	}
+	g.round++
+}
+
+// checkGenerateFlag generates the first flag after half of the rounds if no
+// tank is lost yet, then regenerates it periodically so that no more flags
+// than one player's number of tanks appear.
+func (g *Game) checkGenerateFlag(round int) {
+	opts := g.Options
+	if !g.flagGenerated {
+		if round > opts.MaxRound/2-1 && len(g.Tanks) == 2*opts.NoOfTanks {
+			g.flagGenerated = true
+			g.GenerateFlag()
+			g.flagCount++
+		}
+		return
+	}
+	if (round-opts.MaxRound/2)%(opts.MaxRound/2/opts.NoOfTanks+1) == 0 {
+		g.GenerateFlag()
+		g.flagCount++
+	}
+}
+
+// Result is the outcome of a game, it marshals like the engine's GameResult.
+type Result struct {
+	Result string `json:"result"`
+	Win    string `json:"win"`
+	State  string `json:"state"`
+	Reason string `json:"reason"`
+
+	// Scores are the final scores by player name.
+	Scores map[string]int `json:"-"`
+}
+
+// Result returns the outcome of the game. Flags only count when the game
+// went the full MaxRound rounds, with no player losing all of its tanks.
+func (g *Game) Result() *Result {
+	flagScore := 0
+	if !g.over && g.round >= g.Options.MaxRound {
+		flagScore = g.Options.FlagScore
+	}
+	scores := g.CountScore(g.Options.TankScore, flagScore)
+	a, b := g.Players[0].Name, g.Players[1].Name
+
+	res := &Result{Scores: scores}
+	switch {
+	case scores[a] > scores[b]:
+		res.Result = "win"
+		res.Win = a
+	case scores[a] < scores[b]:
+		res.Result = "win"
+		res.Win = b
+	default:
+		res.Result = "draw"
+	}
+	res.State = fmt.Sprintf("%s: %d,%s: %d", a, scores[a], b, scores[b])
+	return res
+}
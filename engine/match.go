@@ -0,0 +1,358 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+	"replay"
+	"sync"
+	"time"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+// Contestant is one side of a match: a name and the PlayerService to drive.
+// A *player.PlayerServiceClient connected to a bot is a PlayerService.
+type Contestant struct {
+	Name    string
+	Service player.PlayerService
+}
+
+// Match plays a Game between two contestants the way GameEngine does: it
+// uploads the map, the parameters and the tanks, then every round sends the
+// latest state and asks for new orders.
+type Match struct {
+	Game *Game
+	Map  [][]int32
+	// Seed seeds the random source of the Game before the first round when
+	// it is not 0. The contestants which are Seeders are seeded from it, so
+	// with in-process bots this makes Step reproducible.
+	Seed int64
+	// Recorder, when set, records the whole board of every round and the
+	// orders of both contestants. The caller closes it.
+	Recorder *replay.Recorder
+
+	contestants [2]Contestant
+	interacts   [2]*playerInteract
+	ready       bool
+	setupResult *Result
+}
+
+// Seeder is a PlayerService drawing random numbers in-process, such as a
+// bot.PlayerService. Setup seeds it from the random source of the Game.
+type Seeder interface {
+	Seed(seed int64)
+}
+
+// NewMatch returns a match on the given map, a plays the first side.
+func NewMatch(gameMap [][]int32, opts Options, a, b Contestant) (*Match, error) {
+	if a.Name == b.Name {
+		return nil, fmt.Errorf("contestants must have different names, both are %q", a.Name)
+	}
+	game, err := NewGame(gameMap, opts, a.Name, b.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &Match{Game: game, Map: gameMap, contestants: [2]Contestant{a, b}}, nil
+}
+
+// Run plays the match to the end and returns its result. Both contestants
+// are called concurrently; a contestant which fails or misses the
+// RoundTimeoutMs deadline of a round has its tanks stand still.
+func (m *Match) Run() *Result {
+	if res := m.Setup(); res != nil {
+		return res
+	}
+	for i := range m.interacts {
+		go m.interacts[i].run()
+	}
+	defer func() {
+		for _, pi := range m.interacts {
+			close(pi.states)
+		}
+	}()
+
+	timeout := time.Duration(m.Game.Options.RoundTimeoutMs) * time.Millisecond
+	for !m.Game.Over() {
+		round := m.Game.Round()
+		m.recordState(round)
+		for _, pi := range m.interacts {
+			pi.states <- roundState{round, m.Game.ReportState(pi.name)}
+		}
+		// both contestants play at once, the second waited for is given no
+		// more time than the first
+		deadline := time.Now().Add(timeout)
+		orders := []*player.Order{}
+		for _, pi := range m.interacts {
+			o := pi.waitOrders(round, deadline)
+			m.recordOrders(round, pi.name, o)
+			orders = append(orders, o...)
+		}
+		m.Game.Play(orders)
+		if m.Game.Over() {
+			m.recordState(round + 1)
+		}
+	}
+	res := m.Result()
+	log.Printf("Game result: %s (%s)", res.Result, res.State)
+	return res
+}
+
+// Step plays one round in the calling goroutine, calling the contestants
+// one after the other with no deadline. It is meant for in-process matches:
+// a bot can be stepped through in a debugger, and with Seed set the match
+// plays the same way every time. Step returns false once the match is over.
+func (m *Match) Step() bool {
+	if m.Setup() != nil || m.Game.Over() {
+		return false
+	}
+	round := m.Game.Round()
+	m.recordState(round)
+	states := make([]*player.GameState, len(m.interacts))
+	for i, pi := range m.interacts {
+		states[i] = m.Game.ReportState(pi.name)
+	}
+	orders := []*player.Order{}
+	for i, pi := range m.interacts {
+		o := pi.play(states[i])
+		m.recordOrders(round, pi.name, o)
+		orders = append(orders, o...)
+	}
+	m.Game.Play(orders)
+	if m.Game.Over() {
+		// the board the game ended with
+		m.recordState(round + 1)
+		return false
+	}
+	return true
+}
+
+func (m *Match) recordState(round int) {
+	if err := m.Recorder.State(round, m.Game.FullState()); err != nil {
+		log.Printf("Failed to record round %d: %v", round, err)
+	}
+}
+
+func (m *Match) recordOrders(round int, name string, orders []*player.Order) {
+	if err := m.Recorder.Orders(round, name, orders); err != nil {
+		log.Printf("Failed to record round %d: %v", round, err)
+	}
+}
+
+// Result returns the result of the match, or of the game so far if it is
+// not over yet.
+func (m *Match) Result() *Result {
+	if m.setupResult != nil {
+		return m.setupResult
+	}
+	return m.Game.Result()
+}
+
+// Setup pings the contestants and sends them the map, the parameters and
+// their tanks, both at once. If a contestant fails, the other one wins and
+// Setup returns the result of the match. Run and Step call Setup, it only
+// runs once. The Seeders are seeded first, one after the other, so a seeded
+// match stays reproducible.
+func (m *Match) Setup() *Result {
+	if m.ready {
+		return m.setupResult
+	}
+	m.ready = true
+	if m.Seed != 0 {
+		m.Game.Rand.Seed(m.Seed)
+	}
+	for _, c := range m.contestants {
+		if s, ok := c.Service.(Seeder); ok {
+			s.Seed(m.Game.Rand.Int63())
+		}
+	}
+
+	var wg sync.WaitGroup
+	var errs [2]error
+	for i, c := range m.contestants {
+		m.interacts[i] = &playerInteract{
+			name:    c.Name,
+			service: c.Service,
+			tanks:   m.Game.Players[i].Tanks,
+			states:  make(chan roundState, m.Game.Options.MaxRound+1),
+			orders:  make(chan roundOrders, m.Game.Options.MaxRound+1),
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = m.interacts[i].setup(m.Map, m.Game.Options.Args())
+		}(i)
+	}
+	wg.Wait()
+	if err := m.recordSetup(); err != nil {
+		log.Printf("Failed to record the match: %v", err)
+	}
+
+	a, b := m.contestants[0].Name, m.contestants[1].Name
+	switch {
+	case errs[0] != nil && errs[1] != nil:
+		log.Printf("Failed to set up %s: %v", a, errs[0])
+		log.Printf("Failed to set up %s: %v", b, errs[1])
+		m.setupResult = &Result{Result: "draw", Reason: "Failed to connect to both players."}
+	case errs[0] != nil:
+		log.Printf("Failed to set up %s: %v", a, errs[0])
+		m.setupResult = &Result{Result: "win", Win: b, Reason: "Only connected to " + b}
+	case errs[1] != nil:
+		log.Printf("Failed to set up %s: %v", b, errs[1])
+		m.setupResult = &Result{Result: "win", Win: a, Reason: "Only connected to " + a}
+	}
+	return m.setupResult
+}
+
+func (m *Match) recordSetup() error {
+	if err := m.Recorder.Map(m.Map); err != nil {
+		return err
+	}
+	if err := m.Recorder.Args(m.Game.Options.Args()); err != nil {
+		return err
+	}
+	for _, p := range m.Game.Players {
+		if err := m.Recorder.Tanks(p.Name, p.Tanks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type roundState struct {
+	round int
+	state *player.GameState
+}
+
+type roundOrders struct {
+	round  int
+	orders []*player.Order
+}
+
+// playerInteract talks to one contestant from its own goroutine, so that a
+// slow contestant only loses its own rounds.
+type playerInteract struct {
+	name    string
+	service player.PlayerService
+	tanks   []int32
+	states  chan roundState
+	orders  chan roundOrders
+}
+
+func (pi *playerInteract) setup(gameMap [][]int32, args *player.Args_) error {
+	ok, err := pi.service.Ping()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("ping returned false")
+	}
+	if err := pi.service.UploadMap(gameMap); err != nil {
+		return err
+	}
+	if err := pi.service.UploadParamters(args); err != nil {
+		return err
+	}
+	return pi.service.AssignTanks(pi.tanks)
+}
+
+func (pi *playerInteract) run() {
+	for rs := range pi.states {
+		pi.orders <- roundOrders{rs.round, pi.play(rs.state)}
+	}
+}
+
+// play sends the state to the contestant and returns its verified orders.
+func (pi *playerInteract) play(state *player.GameState) []*player.Order {
+	if err := pi.service.LatestState(state); err != nil {
+		log.Printf("Failed to send state to %s: %v", pi.name, err)
+	}
+	orders, err := pi.service.GetNewOrders()
+	if err != nil {
+		log.Printf("Failed to get orders from %s: %v", pi.name, err)
+		return nil
+	}
+	if err := pi.verify(orders); err != nil {
+		log.Printf("Orders from %s ignored: %v", pi.name, err)
+		return nil
+	}
+	return orders
+}
+
+// waitOrders returns the orders of the given round, or none if they do not
+// arrive before the deadline. Late orders of earlier rounds are dropped.
+func (pi *playerInteract) waitOrders(round int, deadline time.Time) []*player.Order {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	for {
+		select {
+		case ro := <-pi.orders:
+			if ro.round == round {
+				return ro.orders
+			}
+		case <-timer.C:
+			log.Printf("%s timed out in round %d", pi.name, round)
+			return nil
+		}
+	}
+}
+
+// verify rejects the orders of a round if one of them controls an enemy tank,
+// two of them control the same tank, or one of them is malformed.
+func (pi *playerInteract) verify(orders []*player.Order) error {
+	seen := map[int32]bool{}
+	for _, o := range orders {
+		if o == nil {
+			return fmt.Errorf("nil order")
+		}
+		owned := false
+		for _, id := range pi.tanks {
+			if id == o.TankId {
+				owned = true
+			}
+		}
+		if !owned {
+			return fmt.Errorf("try to control enemy's tank: %v", o)
+		}
+		if seen[o.TankId] {
+			return fmt.Errorf("duplicate orders for tank %d", o.TankId)
+		}
+		seen[o.TankId] = true
+		if !ValidOrder(o) {
+			return fmt.Errorf("invalid order: %v", o)
+		}
+	}
+	return nil
+}
+
+// ValidOrder reports whether o is a known order with the parameters it needs.
+func ValidOrder(o *player.Order) bool {
+	switch o.Order {
+	case OrderMove:
+		return true
+	case OrderFire, OrderTurnTo:
+		return o.Dir >= player.Direction_UP && o.Dir <= player.Direction_RIGHT
+	}
+	return false
+}
+
+// IdlePlayer is a PlayerService whose tanks never act. It is a sparring
+// partner for in-process tests of a bot.
+type IdlePlayer struct{}
+
+// Ping implements player.PlayerService.
+func (IdlePlayer) Ping() (bool, error) { return true, nil }
+
+// UploadMap implements player.PlayerService.
+func (IdlePlayer) UploadMap(gamemap [][]int32) error { return nil }
+
+// UploadParamters implements player.PlayerService.
+func (IdlePlayer) UploadParamters(arguments *player.Args_) error { return nil }
+
+// AssignTanks implements player.PlayerService.
+func (IdlePlayer) AssignTanks(tanks []int32) error { return nil }
+
+// LatestState implements player.PlayerService.
+func (IdlePlayer) LatestState(state *player.GameState) error { return nil }
+
+// GetNewOrders implements player.PlayerService.
+func (IdlePlayer) GetNewOrders() ([]*player.Order, error) { return nil, nil }
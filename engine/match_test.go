@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"bot"
+	"gamemap"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+// testMap returns an empty board of size cells a side walled by barriers.
+func testMap(size int) [][]int32 {
+	m := make([][]int32, size)
+	for x := range m {
+		m[x] = make([]int32, size)
+		for y := range m[x] {
+			if x == 0 || y == 0 || x == size-1 || y == size-1 {
+				m[x][y] = 1
+			}
+		}
+	}
+	return m
+}
+
+// movingPlayer is a contestant moving its tanks dir every round, after
+// waiting delay.
+type movingPlayer struct {
+	IdlePlayer
+	dir   player.Direction
+	delay time.Duration
+	tanks []int32
+}
+
+func (p *movingPlayer) AssignTanks(tanks []int32) error {
+	p.tanks = tanks
+	return nil
+}
+
+func (p *movingPlayer) GetNewOrders() ([]*player.Order, error) {
+	time.Sleep(p.delay)
+	orders := []*player.Order{}
+	for _, id := range p.tanks {
+		orders = append(orders, &player.Order{TankId: id, Order: OrderMove, Dir: p.dir})
+	}
+	return orders, nil
+}
+
+func TestRunDeadline(t *testing.T) {
+	timeout := 100 * time.Millisecond
+	// a times out, b answers after the deadline but within the timeout of
+	// a wait started when a's ended
+	a := &movingPlayer{dir: player.Direction_DOWN, delay: 3 * timeout}
+	b := &movingPlayer{dir: player.Direction_UP, delay: timeout * 3 / 2}
+	opts := Options{NoOfTanks: 1, TankSpeed: 1, ShellSpeed: 2, TankHP: 1, TankScore: 1, FlagScore: 1, MaxRound: 1, RoundTimeoutMs: int(timeout / time.Millisecond)}
+	m, err := NewMatch(testMap(9), opts, Contestant{"a", a}, Contestant{"b", b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Run()
+	if pos := m.Game.Tank(2).Pos; pos != (Position{7, 7}) {
+		t.Errorf("the late orders of b were played, its tank is at %v", pos)
+	}
+}
+
+// stepMatch plays a seeded match of two in-process bots round by round and
+// returns the board after every round.
+func stepMatch(t *testing.T, seed int64) []*player.GameState {
+	m, err := gamemap.LoadFile("../game_engine/maps/secondweekmap.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := bot.NewPlayerService(bot.Config{Strategy: bot.Default, Name: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := bot.NewPlayerService(bot.Config{Strategy: "hunt", Name: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{NoOfTanks: 4, TankSpeed: 1, ShellSpeed: 2, TankHP: 1, TankScore: 1, FlagScore: 1, MaxRound: 100, RoundTimeoutMs: 2000}
+	match, err := NewMatch(m, opts, Contestant{"a", a}, Contestant{"b", b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	match.Seed = seed
+	if res := match.Setup(); res != nil {
+		t.Fatalf("setup failed: %s", res.Reason)
+	}
+	states := []*player.GameState{match.Game.FullState()}
+	for more := true; more; {
+		more = match.Step()
+		if match.Game.Round() != len(states) {
+			t.Fatalf("round %d after %d steps", match.Game.Round(), len(states))
+		}
+		states = append(states, match.Game.FullState())
+		checkRound(t, match.Game, states[len(states)-2], states[len(states)-1])
+	}
+	if !match.Game.Over() {
+		t.Errorf("the match stopped in round %d before it was over", len(states)-1)
+	}
+	return states
+}
+
+// checkRound checks the board after a round against the board before it.
+func checkRound(t *testing.T, g *Game, before, after *player.GameState) {
+	round := g.Round()
+	prev := map[int32]*player.Tank{}
+	for _, tank := range before.Tanks {
+		prev[tank.ID] = tank
+	}
+	cells := map[Position]int32{}
+	for _, tank := range after.Tanks {
+		pos := Position{int(tank.Pos.X), int(tank.Pos.Y)}
+		if g.IsBarrier(pos) {
+			t.Errorf("round %d: tank %d on the barrier %v", round, tank.ID, pos)
+		}
+		if other, ok := cells[pos]; ok {
+			t.Errorf("round %d: tanks %d and %d both on %v", round, other, tank.ID, pos)
+		}
+		cells[pos] = tank.ID
+		p, ok := prev[tank.ID]
+		if !ok {
+			t.Errorf("round %d: tank %d came back", round, tank.ID)
+			continue
+		}
+		if d := abs(int(tank.Pos.X-p.Pos.X)) + abs(int(tank.Pos.Y-p.Pos.Y)); d > g.Options.TankSpeed {
+			t.Errorf("round %d: tank %d went %d cells", round, tank.ID, d)
+		}
+		if tank.Hp > p.Hp {
+			t.Errorf("round %d: tank %d gained HP", round, tank.ID)
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func TestStep(t *testing.T) {
+	bot.SetLogLevel("error")
+	defer bot.SetLogLevel("info")
+	tests := []struct {
+		seed int64
+		// early is whether a player loses all of its tanks before the last
+		// round.
+		early bool
+	}{
+		{14, false},
+		{2, true},
+	}
+	for _, test := range tests {
+		first := stepMatch(t, test.seed)
+		again := stepMatch(t, test.seed)
+		if len(first) != len(again) {
+			t.Fatalf("seed %d: the match lasted %d rounds, then %d", test.seed, len(first)-1, len(again)-1)
+		}
+		for round := range first {
+			if !reflect.DeepEqual(first[round], again[round]) {
+				t.Fatalf("seed %d: round %d played another way with the same seed", test.seed, round)
+			}
+		}
+		if early := len(first)-1 < 100; early != test.early {
+			t.Errorf("seed %d: the match lasted %d rounds, want it to end early %v", test.seed, len(first)-1, test.early)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package engine
+
+import "github.com/eleme/purchaseMeiTuan/player"
+
+// Position is a cell on the map. X is the row and Y is the column, the same
+// way the engine and player.Position use them.
+//
+//	      (0,0) - (0,1) - (0,2)
+//	                | UP
+//	LEFT  (1,0) - (1,1) - (1,2)  RIGHT
+//	                | DOWN
+//	              (2,1)
+type Position struct {
+	X, Y int
+}
+
+// Move returns the neighbouring position in the given direction.
+func (p Position) Move(dir player.Direction) Position {
+	switch dir {
+	case player.Direction_UP:
+		return Position{p.X - 1, p.Y}
+	case player.Direction_DOWN:
+		return Position{p.X + 1, p.Y}
+	case player.Direction_LEFT:
+		return Position{p.X, p.Y - 1}
+	case player.Direction_RIGHT:
+		return Position{p.X, p.Y + 1}
+	}
+	return p
+}
+
+// Withdraw returns the position one step back from the given direction.
+func (p Position) Withdraw(dir player.Direction) Position {
+	return p.Move(Opposite(dir))
+}
+
+// Opposite returns the reverse of dir.
+func Opposite(dir player.Direction) player.Direction {
+	switch dir {
+	case player.Direction_UP:
+		return player.Direction_DOWN
+	case player.Direction_DOWN:
+		return player.Direction_UP
+	case player.Direction_LEFT:
+		return player.Direction_RIGHT
+	case player.Direction_RIGHT:
+		return player.Direction_LEFT
+	}
+	return dir
+}
+
+func (p Position) toPlayer() *player.Position {
+	return &player.Position{X: int32(p.X), Y: int32(p.Y)}
+}
+
+// Shell is a flying shell. A shell carries the ID of the tank that fired it.
+// Shells and tanks move at the speeds of the match Options.
+type Shell struct {
+	ID        int32
+	Pos       Position
+	Dir       player.Direction
+	Destroyed bool
+}
+
+// Tank is a tank on the map.
+type Tank struct {
+	ID        int32
+	Pos       Position
+	Dir       player.Direction
+	HP        int32
+	Destroyed bool
+
+	shell *Shell
+}
+
+// NewTank returns a tank with the given HP.
+func NewTank(id int32, pos Position, dir player.Direction, hp int) *Tank {
+	return &Tank{ID: id, Pos: pos, Dir: dir, HP: int32(hp)}
+}
+
+// fireAt returns a new shell in front of the tank, or nil if the previous
+// shell of the tank is still flying.
+func (t *Tank) fireAt(dir player.Direction) *Shell {
+	if t.shell != nil && !t.shell.Destroyed {
+		return nil
+	}
+	t.shell = &Shell{ID: t.ID, Pos: t.Pos.Move(dir), Dir: dir}
+	return t.shell
+}
+
+// Fired reports whether the shell of the tank is still flying.
+func (t *Tank) Fired() bool {
+	return t.shell != nil && !t.shell.Destroyed
+}
+
+func (t *Tank) hit() {
+	t.HP--
+	if t.HP <= 0 {
+		t.Destroyed = true
+	}
+}
+
+func (t *Tank) toPlayer() *player.Tank {
+	return &player.Tank{ID: t.ID, Pos: t.Pos.toPlayer(), Dir: t.Dir, Hp: t.HP}
+}
+
+// Player is one side of a match.
+type Player struct {
+	Name  string
+	Tanks []int32
+	Flags int
+}
+
+func (p *Player) owns(id int32) bool {
+	for _, t := range p.Tanks {
+		if t == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Options are the match parameters, the same ones GameEngine takes on its
+// command line.
+type Options struct {
+	NoOfTanks      int
+	TankSpeed      int
+	ShellSpeed     int
+	TankHP         int
+	TankScore      int
+	FlagScore      int
+	MaxRound       int
+	RoundTimeoutMs int
+}
+
+// Args returns the options as sent to players by UploadParamters.
+func (o Options) Args() *player.Args_ {
+	return &player.Args_{
+		TankSpeed:        int32(o.TankSpeed),
+		ShellSpeed:       int32(o.ShellSpeed),
+		TankHP:           int32(o.TankHP),
+		TankScore:        int32(o.TankScore),
+		FlagScore:        int32(o.FlagScore),
+		MaxRound:         int32(o.MaxRound),
+		RoundTimeoutInMs: int32(o.RoundTimeoutMs),
+	}
+}
@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+// ConnectTimeout is how long Connect keeps retrying to reach a player.
+const ConnectTimeout = 60 * time.Second
+
+// Connect returns a client for the player at addr, speaking the binary
+// protocol like GameEngine. The connection is retried until ConnectTimeout,
+// a client which never connects fails its first call. timeout bounds every
+// call to the player.
+func Connect(addr string, timeout time.Duration) player.PlayerService {
+	deadline := time.Now().Add(ConnectTimeout)
+	for {
+		log.Printf("Connecting to %s", addr)
+		transport, err := thrift.NewTSocketTimeout(addr, timeout)
+		if err == nil {
+			err = transport.Open()
+		}
+		if err == nil {
+			log.Printf("Succeed to connect to %s", addr)
+			return player.NewPlayerServiceClientFactory(transport, thrift.NewTBinaryProtocolFactoryDefault())
+		}
+		log.Printf("Failed to connect to %s: %v", addr, err)
+		if time.Now().After(deadline) {
+			return unreachable{addr}
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// Disconnect closes the connection of a client returned by Connect. Other
+// players are left alone.
+func Disconnect(service player.PlayerService) {
+	if c, ok := service.(*player.PlayerServiceClient); ok {
+		c.Transport.Close()
+	}
+}
+
+// unreachable is the PlayerService of a player Connect failed to reach.
+type unreachable struct {
+	addr string
+}
+
+func (u unreachable) Ping() (bool, error) {
+	return false, u.err()
+}
+
+func (u unreachable) UploadMap(gamemap [][]int32) error { return u.err() }
+
+func (u unreachable) UploadParamters(arguments *player.Args_) error { return u.err() }
+
+func (u unreachable) AssignTanks(tanks []int32) error { return u.err() }
+
+func (u unreachable) LatestState(state *player.GameState) error { return u.err() }
+
+func (u unreachable) GetNewOrders() ([]*player.Order, error) { return nil, u.err() }
+
+func (u unreachable) err() error {
+	return fmt.Errorf("failed to connect to %s", u.addr)
+}
@@ -0,0 +1,421 @@
+// Package engine is a Go port of the referee in game_engine. It resolves a
+// round exactly the way ele.me.hackathon.tank.GameStateMachine does, so the
+// bots can be tested without a JVM.
+package engine
+
+import "github.com/eleme/purchaseMeiTuan/player"
+
+// Order names accepted by the engine.
+const (
+	OrderMove   = "move"
+	OrderFire   = "fire"
+	OrderTurnTo = "turnTo"
+)
+
+// StateMachine holds the board and resolves the orders of one round.
+type StateMachine struct {
+	// Map is the board, 0 empty, 1 barrier, 2 forest.
+	Map [][]int32
+	// Tanks are the tanks still alive, in ID order.
+	Tanks []*Tank
+	// Shells are the shells still flying.
+	Shells []*Shell
+	// FlagPos is the flag position, nil when no flag is on the map.
+	FlagPos *Position
+	// Players are the two sides of the match.
+	Players []*Player
+	Options Options
+
+	// DestroyedTanks and DestroyedShells are what the last call to NewOrders
+	// removed from the board.
+	DestroyedTanks  []*Tank
+	DestroyedShells []*Shell
+}
+
+// NewStateMachine returns a state machine for the given board.
+func NewStateMachine(gameMap [][]int32, tanks []*Tank, players []*Player, opts Options) *StateMachine {
+	return &StateMachine{
+		Map:     gameMap,
+		Tanks:   tanks,
+		Players: players,
+		Options: opts,
+	}
+}
+
+// NewOrders resolves one round: shells fly first, then tanks fire, then turn,
+// then move.
+func (sm *StateMachine) NewOrders(orders []*player.Order) {
+	sm.DestroyedTanks = nil
+	sm.DestroyedShells = nil
+
+	sm.evaluateShellsMovement()
+	sm.evaluateFireActions(sm.filterOrders(orders, OrderFire))
+	sm.evaluateTurnDirectionActions(sm.filterOrders(orders, OrderTurnTo))
+	sm.evaluateMoveActions(sm.filterOrders(orders, OrderMove))
+}
+
+// IsBarrier reports whether pos blocks tanks and shells. Cells outside the
+// map are barriers.
+func (sm *StateMachine) IsBarrier(pos Position) bool {
+	if pos.X < 0 || pos.X >= len(sm.Map) || pos.Y < 0 || pos.Y >= len(sm.Map[pos.X]) {
+		return true
+	}
+	return sm.Map[pos.X][pos.Y] == 1
+}
+
+// IsVisible reports whether objects at pos can be seen, i.e. pos is not
+// forest.
+func (sm *StateMachine) IsVisible(pos Position) bool {
+	return sm.IsBarrier(pos) || sm.Map[pos.X][pos.Y] != 2
+}
+
+// Tank returns the living tank with the given ID, or nil.
+func (sm *StateMachine) Tank(id int32) *Tank {
+	for _, t := range sm.Tanks {
+		if t.ID == id {
+			return t
+		}
+	}
+	return nil
+}
+
+// Player returns the player with the given name, or nil.
+func (sm *StateMachine) Player(name string) *Player {
+	for _, p := range sm.Players {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// Owner returns the player owning the tank with the given ID, or nil.
+func (sm *StateMachine) Owner(id int32) *Player {
+	for _, p := range sm.Players {
+		if p.owns(id) {
+			return p
+		}
+	}
+	return nil
+}
+
+func (sm *StateMachine) evaluateShellsMovement() {
+	for i := 0; i < sm.Options.ShellSpeed; i++ {
+		for _, s := range sm.Shells {
+			s.Pos = s.Pos.Move(s.Dir)
+		}
+		for _, s := range sm.Shells {
+			if sm.IsBarrier(s.Pos) {
+				s.Destroyed = true
+			} else if t := sm.tankAt(s.Pos); t != nil {
+				s.Destroyed = true
+				t.hit()
+			}
+		}
+		// evaluate result on each step
+		sm.clearDestroyedTargets()
+	}
+}
+
+func (sm *StateMachine) evaluateFireActions(orders []*player.Order) {
+	// let all tanks fire first so as to simulate all tanks are acting in the
+	// same time, thus even if a tank is destroyed by a new fired shell, it
+	// still has a chance to fire a shell before it dies.
+	newShells := []*Shell{}
+	for _, o := range orders {
+		if s := sm.Tank(o.TankId).fireAt(o.Dir); s != nil {
+			newShells = append(newShells, s)
+		}
+	}
+	for _, s := range newShells {
+		if sm.IsBarrier(s.Pos) {
+			s.Destroyed = true
+			continue
+		}
+		if t := sm.tankAt(s.Pos); t != nil {
+			t.hit()
+			s.Destroyed = true
+		}
+	}
+	for _, s := range newShells {
+		if !s.Destroyed {
+			sm.Shells = append(sm.Shells, s)
+		}
+	}
+	sm.clearDestroyedTargets()
+}
+
+func (sm *StateMachine) evaluateTurnDirectionActions(orders []*player.Order) {
+	for _, o := range orders {
+		sm.Tank(o.TankId).Dir = o.Dir
+	}
+}
+
+// evaluateMoveActions moves tanks one step at a time. The direction of a move
+// order is ignored, tanks always move the way they face.
+func (sm *StateMachine) evaluateMoveActions(orders []*player.Order) {
+	moving := []*Tank{}
+	for _, o := range orders {
+		moving = append(moving, sm.Tank(o.TankId))
+	}
+
+	for i := 0; i < sm.Options.TankSpeed; i++ {
+		moving = removeFaceToFace(moving)
+		for _, t := range moving {
+			t.Pos = t.Pos.Move(t.Dir)
+		}
+		moving = sm.withdrawUntilNoOverlap(moving)
+
+		sm.checkFlag()
+
+		for _, t := range moving {
+			for _, s := range sm.shellsAt(t.Pos) {
+				s.Destroyed = true
+				t.hit()
+			}
+		}
+
+		alive := moving[:0]
+		for _, t := range moving {
+			if !t.Destroyed {
+				alive = append(alive, t)
+			}
+		}
+		moving = alive
+		sm.clearDestroyedTargets()
+	}
+}
+
+// removeFaceToFace drops the tanks which would swap cells with a tank facing
+// them, they stay where they are for the rest of the round.
+func removeFaceToFace(moving []*Tank) []*Tank {
+	f2f := map[*Tank]bool{}
+	for _, t := range moving {
+		next := t.Pos.Move(t.Dir)
+		for _, other := range moving {
+			if other.Pos == next && other.Dir == Opposite(t.Dir) {
+				f2f[t] = true
+			}
+		}
+	}
+	rest := []*Tank{}
+	for _, t := range moving {
+		if !f2f[t] {
+			rest = append(rest, t)
+		}
+	}
+	return rest
+}
+
+// withdrawUntilNoOverlap steps back the moved tanks which ended on a barrier
+// or on another tank until every tank has a cell of its own. A withdrawn tank
+// does not move any further this round.
+func (sm *StateMachine) withdrawUntilNoOverlap(moving []*Tank) []*Tank {
+	for {
+		withdrawn := false
+		for _, t := range sm.invalidTanks() {
+			for i, m := range moving {
+				if m == t {
+					t.Pos = t.Pos.Withdraw(t.Dir)
+					moving = append(moving[:i], moving[i+1:]...)
+					withdrawn = true
+					break
+				}
+			}
+		}
+		if !withdrawn {
+			return moving
+		}
+	}
+}
+
+func (sm *StateMachine) invalidTanks() []*Tank {
+	invalid := []*Tank{}
+	for _, t := range sm.Tanks {
+		if sm.IsBarrier(t.Pos) || sm.countTanksAt(t.Pos) > 1 {
+			invalid = append(invalid, t)
+		}
+	}
+	return invalid
+}
+
+func (sm *StateMachine) countTanksAt(pos Position) int {
+	n := 0
+	for _, t := range sm.Tanks {
+		if t.Pos == pos {
+			n++
+		}
+	}
+	return n
+}
+
+func (sm *StateMachine) tankAt(pos Position) *Tank {
+	for _, t := range sm.Tanks {
+		if t.Pos == pos {
+			return t
+		}
+	}
+	return nil
+}
+
+func (sm *StateMachine) shellsAt(pos Position) []*Shell {
+	shells := []*Shell{}
+	for _, s := range sm.Shells {
+		if !s.Destroyed && s.Pos == pos {
+			shells = append(shells, s)
+		}
+	}
+	return shells
+}
+
+// checkFlag gives the flag to the player whose tank stands on it.
+func (sm *StateMachine) checkFlag() {
+	if sm.FlagPos == nil {
+		return
+	}
+	if t := sm.tankAt(*sm.FlagPos); t != nil {
+		sm.FlagPos = nil
+		if p := sm.Owner(t.ID); p != nil {
+			p.Flags++
+		}
+	}
+}
+
+func (sm *StateMachine) clearDestroyedTargets() {
+	tanks := sm.Tanks[:0]
+	for _, t := range sm.Tanks {
+		if t.Destroyed {
+			sm.DestroyedTanks = append(sm.DestroyedTanks, t)
+		} else {
+			tanks = append(tanks, t)
+		}
+	}
+	sm.Tanks = tanks
+
+	shells := sm.Shells[:0]
+	for _, s := range sm.Shells {
+		if s.Destroyed {
+			sm.DestroyedShells = append(sm.DestroyedShells, s)
+		} else {
+			shells = append(shells, s)
+		}
+	}
+	sm.Shells = shells
+}
+
+// filterOrders returns the orders with the given name whose tank is alive.
+func (sm *StateMachine) filterOrders(orders []*player.Order, name string) []*player.Order {
+	res := []*player.Order{}
+	for _, o := range orders {
+		if o != nil && o.Order == name && sm.Tank(o.TankId) != nil {
+			res = append(res, o)
+		}
+	}
+	return res
+}
+
+// GenerateFlag puts the flag at the map center. A tank already standing there
+// captures it at once.
+func (sm *StateMachine) GenerateFlag() Position {
+	pos := Position{len(sm.Map) / 2, len(sm.Map) / 2}
+	sm.FlagPos = &pos
+	sm.checkFlag()
+	return pos
+}
+
+// ReportState returns the state as seen by the named player: its own tanks,
+// the enemy tanks and the shells which are not in forest.
+func (sm *StateMachine) ReportState(name string) *player.GameState {
+	state := &player.GameState{
+		Tanks:  []*player.Tank{},
+		Shells: []*player.Shell{},
+	}
+	// own tanks first, then the enemy's tanks if they are visible
+	if p := sm.Player(name); p != nil {
+		for _, id := range p.Tanks {
+			if t := sm.Tank(id); t != nil {
+				state.Tanks = append(state.Tanks, t.toPlayer())
+			}
+		}
+	}
+	for _, p := range sm.Players {
+		if p.Name == name {
+			continue
+		}
+		for _, id := range p.Tanks {
+			if t := sm.Tank(id); t != nil && sm.IsVisible(t.Pos) {
+				state.Tanks = append(state.Tanks, t.toPlayer())
+			}
+		}
+	}
+
+	for _, s := range sm.Shells {
+		if sm.IsVisible(s.Pos) {
+			state.Shells = append(state.Shells, &player.Shell{ID: s.ID, Pos: s.Pos.toPlayer(), Dir: s.Dir})
+		}
+	}
+	for _, p := range sm.Players {
+		if p.Name == name {
+			state.YourFlagNo = int32(p.Flags)
+		} else {
+			state.EnemyFlagNo = int32(p.Flags)
+		}
+	}
+	if sm.FlagPos != nil {
+		state.FlagPos = sm.FlagPos.toPlayer()
+	}
+	return state
+}
+
+// FullState returns the whole board with no fog: every tank and shell, the
+// flags of the first player as YourFlagNo and of the second as EnemyFlagNo.
+func (sm *StateMachine) FullState() *player.GameState {
+	state := &player.GameState{
+		Tanks:  []*player.Tank{},
+		Shells: []*player.Shell{},
+	}
+	for _, t := range sm.Tanks {
+		state.Tanks = append(state.Tanks, t.toPlayer())
+	}
+	for _, s := range sm.Shells {
+		state.Shells = append(state.Shells, &player.Shell{ID: s.ID, Pos: s.Pos.toPlayer(), Dir: s.Dir})
+	}
+	if len(sm.Players) == 2 {
+		state.YourFlagNo = int32(sm.Players[0].Flags)
+		state.EnemyFlagNo = int32(sm.Players[1].Flags)
+	}
+	if sm.FlagPos != nil {
+		state.FlagPos = sm.FlagPos.toPlayer()
+	}
+	return state
+}
+
+// GameOver reports whether a player has lost all of its tanks.
+func (sm *StateMachine) GameOver() bool {
+	for _, p := range sm.Players {
+		if sm.aliveTanks(p) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (sm *StateMachine) aliveTanks(p *Player) int {
+	n := 0
+	for _, id := range p.Tanks {
+		if sm.Tank(id) != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// CountScore returns the score of every player: tankScore for each living
+// tank plus flagScore for each captured flag.
+func (sm *StateMachine) CountScore(tankScore, flagScore int) map[string]int {
+	scores := map[string]int{}
+	for _, p := range sm.Players {
+		scores[p.Name] = sm.aliveTanks(p)*tankScore + p.Flags*flagScore
+	}
+	return scores
+}
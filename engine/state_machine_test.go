@@ -0,0 +1,315 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+// The tests are those of GameStateMachineTest in game_engine, resolved by the
+// port the same way.
+
+// testMachine returns the board of MapFactory, 18 cells a side with a row of
+// forest at 16, with tank 1 of playerA on 1,1 with 1 HP and tank 2 of playerB
+// on 1,5 with 2 HP, both facing down.
+func testMachine() (sm *StateMachine, a, b *Tank) {
+	m := testMap(18)
+	for y := 1; y < 17; y++ {
+		m[16][y] = 2
+	}
+	a = NewTank(1, Position{1, 1}, player.Direction_DOWN, 1)
+	b = NewTank(2, Position{1, 5}, player.Direction_DOWN, 2)
+	players := []*Player{{Name: "playerA", Tanks: []int32{1}}, {Name: "playerB", Tanks: []int32{2}}}
+	opts := Options{NoOfTanks: 1, TankSpeed: 1, ShellSpeed: 2, TankHP: 2, TankScore: 1, FlagScore: 1, MaxRound: 100, RoundTimeoutMs: 2000}
+	return NewStateMachine(m, []*Tank{a, b}, players, opts), a, b
+}
+
+func order(id int32, name string, dir player.Direction) *player.Order {
+	return &player.Order{TankId: id, Order: name, Dir: dir}
+}
+
+func TestShellMoveToTank(t *testing.T) {
+	sm, a, _ := testMachine()
+	a.Pos = Position{2, 2}
+	shell := &Shell{ID: 1, Pos: Position{2, 4}, Dir: player.Direction_LEFT}
+	sm.Shells = append(sm.Shells, shell)
+
+	sm.NewOrders(nil)
+
+	if !a.Destroyed || !shell.Destroyed {
+		t.Errorf("tank destroyed %v, shell destroyed %v, want both", a.Destroyed, shell.Destroyed)
+	}
+}
+
+func TestShellPassThroughTank(t *testing.T) {
+	sm, _, b := testMachine()
+	b.Pos = Position{5, 5}
+	shell := &Shell{ID: 2, Pos: Position{6, 5}, Dir: player.Direction_UP}
+	sm.Shells = append(sm.Shells, shell)
+
+	sm.NewOrders(nil)
+
+	if b.HP != 1 || !shell.Destroyed {
+		t.Errorf("tank has %d HP, shell destroyed %v, want 1 HP and the shell gone", b.HP, shell.Destroyed)
+	}
+}
+
+func TestShellsEndOnSameTank(t *testing.T) {
+	sm, a, _ := testMachine()
+	a.Pos = Position{2, 2}
+	sm.Shells = append(sm.Shells,
+		&Shell{ID: 1, Pos: Position{2, 4}, Dir: player.Direction_LEFT},
+		&Shell{ID: 2, Pos: Position{4, 2}, Dir: player.Direction_UP})
+
+	sm.NewOrders(nil)
+
+	if !a.Destroyed || len(sm.Shells) != 0 {
+		t.Errorf("tank destroyed %v with %d shells left, want destroyed and none", a.Destroyed, len(sm.Shells))
+	}
+}
+
+func TestShellMovementStepByStep(t *testing.T) {
+	sm, a, _ := testMachine()
+	a.Pos = Position{2, 2}
+	first := &Shell{ID: 1, Pos: Position{2, 3}, Dir: player.Direction_LEFT}
+	second := &Shell{ID: 2, Pos: Position{2, 4}, Dir: player.Direction_LEFT}
+	sm.Shells = append(sm.Shells, first, second)
+
+	sm.NewOrders(nil)
+
+	// the first shell destroys the tank on its first step, the second finds
+	// the cell empty on its second
+	if !a.Destroyed || !first.Destroyed || second.Destroyed {
+		t.Errorf("tank destroyed %v, shells destroyed %v and %v, want true, true, false", a.Destroyed, first.Destroyed, second.Destroyed)
+	}
+}
+
+func TestFireOrders(t *testing.T) {
+	sm, _, _ := testMachine()
+	orders := []*player.Order{
+		order(1, OrderFire, player.Direction_RIGHT),
+		order(2, OrderFire, player.Direction_LEFT),
+	}
+
+	sm.NewOrders(orders)
+	if len(sm.Shells) != 2 {
+		t.Fatalf("%d shells, want 2", len(sm.Shells))
+	}
+	// a tank fires again only once its shell is gone
+	sm.NewOrders(orders)
+	if len(sm.Shells) != 2 {
+		t.Errorf("%d shells after firing again, want 2", len(sm.Shells))
+	}
+}
+
+func TestFireTowardsEachOther(t *testing.T) {
+	sm, a, b := testMachine()
+	b.Pos = Position{1, 2}
+
+	sm.NewOrders([]*player.Order{
+		order(1, OrderFire, player.Direction_RIGHT),
+		order(2, OrderFire, player.Direction_LEFT),
+	})
+
+	if len(sm.Shells) != 0 || !a.Destroyed || b.HP != 1 {
+		t.Errorf("%d shells, A destroyed %v, B %d HP, want none, true, 1", len(sm.Shells), a.Destroyed, b.HP)
+	}
+}
+
+func TestTurnToOrders(t *testing.T) {
+	sm, a, b := testMachine()
+
+	sm.NewOrders([]*player.Order{
+		order(1, OrderTurnTo, player.Direction_RIGHT),
+		order(2, OrderTurnTo, player.Direction_LEFT),
+	})
+
+	if a.Dir != player.Direction_RIGHT || b.Dir != player.Direction_LEFT {
+		t.Errorf("tanks face %v and %v, want RIGHT and LEFT", a.Dir, b.Dir)
+	}
+	if a.Pos != (Position{1, 1}) || b.Pos != (Position{1, 5}) {
+		t.Errorf("turning moved the tanks to %v and %v", a.Pos, b.Pos)
+	}
+}
+
+func TestMoveOrders(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup places the tanks and returns the ones to move.
+		setup func(sm *StateMachine, a, b *Tank) []*Tank
+		// want are the positions of A and B after the round.
+		want [2]Position
+	}{
+		// the direction of the order is ignored, the tanks face down
+		{"normal", func(sm *StateMachine, a, b *Tank) []*Tank {
+			return []*Tank{a, b}
+		}, [2]Position{{2, 1}, {2, 5}}},
+		{"barrier", func(sm *StateMachine, a, b *Tank) []*Tank {
+			a.Dir = player.Direction_UP
+			return []*Tank{a}
+		}, [2]Position{{1, 1}, {1, 5}}},
+		{"same cell", func(sm *StateMachine, a, b *Tank) []*Tank {
+			b.Pos, b.Dir = Position{2, 2}, player.Direction_LEFT
+			return []*Tank{a, b}
+		}, [2]Position{{1, 1}, {2, 2}}},
+		{"cell left by another", func(sm *StateMachine, a, b *Tank) []*Tank {
+			b.Pos, b.Dir = Position{1, 2}, player.Direction_LEFT
+			return []*Tank{a, b}
+		}, [2]Position{{2, 1}, {1, 1}}},
+		{"cell of another", func(sm *StateMachine, a, b *Tank) []*Tank {
+			b.Pos, b.Dir = Position{1, 2}, player.Direction_LEFT
+			return []*Tank{b}
+		}, [2]Position{{1, 1}, {1, 2}}},
+		{"face to face", func(sm *StateMachine, a, b *Tank) []*Tank {
+			a.Dir = player.Direction_RIGHT
+			b.Pos, b.Dir = Position{1, 2}, player.Direction_LEFT
+			return []*Tank{a, b}
+		}, [2]Position{{1, 1}, {1, 2}}},
+		// C takes the cell B leaves for A, B steps back onto A which steps
+		// back too
+		{"withdraw makes overlap", func(sm *StateMachine, a, b *Tank) []*Tank {
+			a.Dir = player.Direction_RIGHT
+			b.Pos, b.Dir = Position{1, 2}, player.Direction_RIGHT
+			c := NewTank(3, Position{2, 3}, player.Direction_UP, 1)
+			sm.Tanks = append(sm.Tanks, c)
+			sm.Player("playerB").Tanks = append(sm.Player("playerB").Tanks, 3)
+			return []*Tank{a, b, c}
+		}, [2]Position{{1, 1}, {1, 2}}},
+	}
+	for _, test := range tests {
+		sm, a, b := testMachine()
+		before := map[*Tank]Position{}
+		orders := []*player.Order{}
+		for _, tank := range test.setup(sm, a, b) {
+			before[tank] = tank.Pos
+			orders = append(orders, order(tank.ID, OrderMove, player.Direction_RIGHT))
+		}
+		others := append([]*Tank{}, sm.Tanks[2:]...)
+
+		sm.NewOrders(orders)
+
+		if a.Pos != test.want[0] || b.Pos != test.want[1] {
+			t.Errorf("%s: tanks at %v and %v, want %v", test.name, a.Pos, b.Pos, test.want)
+		}
+		for _, tank := range others {
+			if tank.Pos != before[tank] {
+				t.Errorf("%s: tank %d moved from %v to %v", test.name, tank.ID, before[tank], tank.Pos)
+			}
+		}
+	}
+}
+
+func TestMoveToShell(t *testing.T) {
+	for _, speed := range []int{1, 2} {
+		sm, a, b := testMachine()
+		sm.Options.TankSpeed = speed
+		// the shells stop on the cells the tanks move to
+		first := &Shell{ID: 1, Pos: Position{2, 3}, Dir: player.Direction_LEFT}
+		second := &Shell{ID: 2, Pos: Position{2, 7}, Dir: player.Direction_LEFT}
+		sm.Shells = append(sm.Shells, first, second)
+
+		sm.NewOrders([]*player.Order{
+			order(1, OrderMove, player.Direction_DOWN),
+			order(2, OrderMove, player.Direction_DOWN),
+		})
+
+		if !a.Destroyed || b.Destroyed || b.HP != 1 {
+			t.Errorf("speed %d: A destroyed %v, B destroyed %v with %d HP, want A destroyed and B with 1 HP", speed, a.Destroyed, b.Destroyed, b.HP)
+		}
+		// B goes on through the shell at speed 2
+		if want := (Position{1 + speed, 5}); b.Pos != want {
+			t.Errorf("speed %d: B at %v, want %v", speed, b.Pos, want)
+		}
+		if !first.Destroyed || !second.Destroyed {
+			t.Errorf("speed %d: shells destroyed %v and %v, want both", speed, first.Destroyed, second.Destroyed)
+		}
+	}
+}
+
+func TestGetFlag(t *testing.T) {
+	sm, a, _ := testMachine()
+	flag := sm.GenerateFlag()
+	a.Pos, a.Dir = flag.Move(player.Direction_DOWN), player.Direction_UP
+
+	sm.NewOrders([]*player.Order{order(1, OrderMove, player.Direction_UP)})
+
+	if got := sm.Player("playerA").Flags; got != 1 {
+		t.Errorf("playerA has %d flags, want 1", got)
+	}
+	if got := sm.Player("playerB").Flags; got != 0 {
+		t.Errorf("playerB has %d flags, want 0", got)
+	}
+}
+
+func TestGenerateFlag(t *testing.T) {
+	sm, a, _ := testMachine()
+	a.Pos = Position{len(sm.Map) / 2, len(sm.Map) / 2}
+	if sm.FlagPos != nil || sm.Player("playerA").Flags != 0 {
+		t.Fatalf("flag at %v before it is generated", sm.FlagPos)
+	}
+
+	sm.GenerateFlag()
+
+	if got := sm.Player("playerA").Flags; got != 1 {
+		t.Errorf("playerA has %d flags, want the one generated under its tank", got)
+	}
+}
+
+func TestReportState(t *testing.T) {
+	sm, a, _ := testMachine()
+	a.Pos = Position{16, 1}
+	sm.Shells = append(sm.Shells,
+		&Shell{ID: 1, Pos: Position{16, 4}, Dir: player.Direction_LEFT},
+		&Shell{ID: 2, Pos: Position{6, 5}, Dir: player.Direction_UP})
+	sm.GenerateFlag()
+
+	tests := []struct {
+		name          string
+		tanks, shells int
+	}{
+		{"playerA", 2, 1},
+		// tank 1 is in the forest
+		{"playerB", 1, 1},
+	}
+	for _, test := range tests {
+		state := sm.ReportState(test.name)
+		if len(state.Tanks) != test.tanks || len(state.Shells) != test.shells {
+			t.Errorf("%s sees %d tanks and %d shells, want %d and %d", test.name, len(state.Tanks), len(state.Shells), test.tanks, test.shells)
+		}
+		if state.FlagPos == nil || *state.FlagPos != *sm.FlagPos.toPlayer() {
+			t.Errorf("%s sees the flag at %v, want %v", test.name, state.FlagPos, sm.FlagPos)
+		}
+	}
+}
+
+// destroy removes the tank from the board the way a shell does.
+func destroy(sm *StateMachine, tank *Tank) {
+	tank.Destroyed = true
+	sm.clearDestroyedTargets()
+}
+
+func TestJudgeGameOver(t *testing.T) {
+	sm, a, b := testMachine()
+	if sm.GameOver() {
+		t.Error("game over with both tanks alive")
+	}
+	destroy(sm, a)
+	if !sm.GameOver() {
+		t.Error("game not over once playerA has no tank")
+	}
+	destroy(sm, b)
+	if !sm.GameOver() {
+		t.Error("game not over once no player has a tank")
+	}
+}
+
+func TestCountScore(t *testing.T) {
+	sm, a, _ := testMachine()
+	if scores := sm.CountScore(1, 1); scores["playerA"] != scores["playerB"] {
+		t.Errorf("scores %v, want them equal", scores)
+	}
+	destroy(sm, a)
+	if scores := sm.CountScore(1, 1); scores["playerA"] != 0 || scores["playerB"] != 1 {
+		t.Errorf("scores %v, want playerA 0 and playerB 1", scores)
+	}
+}
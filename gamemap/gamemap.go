@@ -0,0 +1,177 @@
+// Package gamemap reads the map files of game_engine/maps into the [][]int32
+// sent by UploadMap, and checks that a map follows the competition rules.
+//
+// A map file starts with a "size: N" line followed by N lines of N space
+// separated cells. Lines starting with "//" are comments.
+package gamemap
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Cell values of a map.
+const (
+	Empty   = 0
+	Barrier = 1
+	Forest  = 2
+	Flag    = 3
+)
+
+// MaxSize is the largest map the bots can hold.
+const MaxSize = 50
+
+// Error is a problem found in a map. Line and Column are 1-based positions
+// in the map file; maps which were not read from a file use the row and the
+// cell index instead.
+type Error struct {
+	Line, Column int
+	Msg          string
+}
+
+func (e *Error) Error() string {
+	if e.Line == 0 {
+		return e.Msg
+	}
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// ErrorList is the list of all problems found in a map.
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+	var buf bytes.Buffer
+	for i, e := range l {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString(e.Error())
+	}
+	return buf.String()
+}
+
+// Load reads and validates a map. A map which cannot be read gives an
+// *Error and no cells. A map which breaks the competition rules gives its
+// cells along with an ErrorList at the file positions of the cells, so
+// that it can still be played the way the Java engine does.
+func Load(r io.Reader) ([][]int32, error) {
+	cells, pos, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+	if errs := validate(cells); len(errs) > 0 {
+		for _, e := range errs {
+			// validate reports cells as 1-based row and index, turn them
+			// into file positions.
+			if e.Line > 0 && e.Line <= len(pos) && e.Column > 0 && e.Column <= len(pos[e.Line-1]) {
+				e.Line, e.Column = pos[e.Line-1][e.Column-1].line, pos[e.Line-1][e.Column-1].column
+			}
+		}
+		return cells, errs
+	}
+	return cells, nil
+}
+
+// LoadFile reads and validates the map file at path, the way Load does.
+func LoadFile(path string) ([][]int32, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Parse reads a map without checking the competition rules.
+func Parse(r io.Reader) ([][]int32, error) {
+	cells, _, err := parse(r)
+	return cells, err
+}
+
+// filePos is the position of a cell in the map file.
+type filePos struct {
+	line, column int
+}
+
+func parse(r io.Reader) ([][]int32, [][]filePos, error) {
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	size := -1
+	for size < 0 && scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if strings.HasPrefix(line, "//") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "size") {
+			return nil, nil, &Error{lineNo, 1, `expect "size: N" before the cells`}
+		}
+		i := strings.Index(line, ":")
+		if i < 0 {
+			return nil, nil, &Error{lineNo, len(line) + 1, `missing ":" after size`}
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(line[i+1:]))
+		if err != nil || n <= 0 {
+			return nil, nil, &Error{lineNo, i + 2, fmt.Sprintf("invalid size %q", strings.TrimSpace(line[i+1:]))}
+		}
+		if n > MaxSize {
+			return nil, nil, &Error{lineNo, i + 2, fmt.Sprintf("size %d is larger than %d", n, MaxSize)}
+		}
+		size = n
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	if size < 0 {
+		return nil, nil, &Error{lineNo + 1, 1, "no size found"}
+	}
+
+	cells := make([][]int32, 0, size)
+	pos := make([][]filePos, 0, size)
+	for len(cells) < size && scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if strings.HasPrefix(line, "//") {
+			continue
+		}
+		row := make([]int32, 0, size)
+		rowPos := make([]filePos, 0, size)
+		for col := 0; col < len(line); {
+			if line[col] == ' ' || line[col] == '\t' || line[col] == '\r' {
+				col++
+				continue
+			}
+			end := col
+			for end < len(line) && line[end] != ' ' && line[end] != '\t' && line[end] != '\r' {
+				end++
+			}
+			v, err := strconv.Atoi(line[col:end])
+			if err != nil || v < Empty || v > Flag {
+				return nil, nil, &Error{lineNo, col + 1, fmt.Sprintf("invalid cell %q, expect 0, 1, 2 or 3", line[col:end])}
+			}
+			if len(row) == size {
+				return nil, nil, &Error{lineNo, col + 1, fmt.Sprintf("expect %d cells, but the line has more", size)}
+			}
+			row = append(row, int32(v))
+			rowPos = append(rowPos, filePos{lineNo, col + 1})
+			col = end
+		}
+		if len(row) != size {
+			return nil, nil, &Error{lineNo, len(line) + 1, fmt.Sprintf("expect %d cells, but read %d", size, len(row))}
+		}
+		cells = append(cells, row)
+		pos = append(pos, rowPos)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(cells) < size {
+		return nil, nil, &Error{lineNo + 1, 1, fmt.Sprintf("expect %d rows, but read %d", size, len(cells))}
+	}
+	return cells, pos, nil
+}
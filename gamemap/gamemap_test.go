@@ -0,0 +1,115 @@
+package gamemap
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cells := [][]int32{{0, 1}, {1, 0}}
+	tests := []struct {
+		name  string
+		input string
+		want  [][]int32
+		// line and column are where the error is, 0 if there is none.
+		line, column int
+	}{
+		{"plain", "size: 2\n0 1\n1 0\n", cells, 0, 0},
+		{"comments and blank lines", "// a map\n\nsize: 2\n// the rows\n0 1\n1 0\n\n", cells, 0, 0},
+		{"tabs and CRLF", "size: 2\r\n0\t1\r\n1 0\r\n", cells, 0, 0},
+		{"no size", "0 1\n1 0\n", nil, 1, 1},
+		{"empty", "// nothing\n", nil, 2, 1},
+		{"missing colon", "size 2\n", nil, 1, 7},
+		{"invalid size", "size: x\n", nil, 1, 6},
+		{"too large", "size: 51\n", nil, 1, 6},
+		{"short row", "size: 2\n0\n1 0\n", nil, 2, 2},
+		{"long row", "size: 2\n0 1 1\n1 0\n", nil, 2, 5},
+		{"invalid cell", "size: 2\n0 4\n1 0\n", nil, 2, 3},
+		{"blank line in the rows", "size: 2\n0 1\n\n1 0\n", nil, 3, 1},
+		{"missing rows", "size: 2\n0 1\n", nil, 3, 1},
+	}
+	for _, test := range tests {
+		got, err := Parse(strings.NewReader(test.input))
+		if test.line == 0 {
+			if err != nil || !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%s: Parse gave %v, %v, want %v", test.name, got, err, test.want)
+			}
+			continue
+		}
+		e, ok := err.(*Error)
+		if !ok || e.Line != test.line || e.Column != test.column {
+			t.Errorf("%s: Parse gave %v, want an error at line %d, column %d", test.name, err, test.line, test.column)
+		}
+	}
+}
+
+// validMap is the smallest map following the rules.
+const validMap = `size: 6
+1 1 1 1 1 1
+1 0 0 0 0 1
+1 0 0 0 0 1
+1 0 0 0 0 1
+1 0 0 0 0 1
+1 1 1 1 1 1
+`
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		// cells is whether Load gives the cells.
+		cells bool
+		// line and column are where the first error is, 0 if there is
+		// none.
+		line, column int
+	}{
+		{"valid", validMap, true, 0, 0},
+		{"unreadable", "size: 6\n1 1\n", false, 2, 4},
+		// row 2, cell 4 breaks the symmetry, it is on line 5 after the
+		// comments and column 7
+		{"broken rule", "// a map\n\n" + strings.Replace(validMap, "1 0 0 0 0 1", "1 0 0 2 0 1", 1), true, 5, 7},
+	}
+	for _, test := range tests {
+		cells, err := Load(strings.NewReader(test.input))
+		if (cells != nil) != test.cells {
+			t.Errorf("%s: Load gave cells %v, want %v", test.name, cells != nil, test.cells)
+		}
+		if test.line == 0 {
+			if err != nil {
+				t.Errorf("%s: %v", test.name, err)
+			}
+			continue
+		}
+		var e *Error
+		switch err := err.(type) {
+		case *Error:
+			e = err
+		case ErrorList:
+			e = err[0]
+		}
+		if e == nil || e.Line != test.line || e.Column != test.column {
+			t.Errorf("%s: Load gave %v, want an error at line %d, column %d", test.name, err, test.line, test.column)
+		}
+	}
+}
+
+func TestLoadShippedMaps(t *testing.T) {
+	files := []string{}
+	for _, glob := range []string{"../game_engine/maps/*.txt", "../play_game/data/*.txt"} {
+		matches, err := filepath.Glob(glob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		t.Fatal("no maps found")
+	}
+	for _, file := range files {
+		if _, err := LoadFile(file); err != nil {
+			t.Errorf("%s: %v", file, err)
+		}
+	}
+}
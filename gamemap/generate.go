@@ -0,0 +1,178 @@
+package gamemap
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+)
+
+// MaxGenerateSize is the largest map Generate makes, the competition maps are
+// below 30 cells a side.
+const MaxGenerateSize = 29
+
+// GenerateOptions control Generate.
+type GenerateOptions struct {
+	// Size is the width and height of the map, from 6 to MaxGenerateSize.
+	// The competition uses odd sizes.
+	Size int
+	// Barriers and Forests are the share of the inner cells turned into
+	// barriers and forest, between 0 and 1.
+	Barriers float64
+	Forests  float64
+	// Seed makes the map reproducible.
+	Seed int64
+}
+
+// Generate returns a random map which passes Validate: walled border, free
+// flag cell at the center, point symmetric about the center, free spawn
+// cells and connected spawn corners. Barriers are laid as short walls and
+// forest as patches.
+func Generate(opts GenerateOptions) ([][]int32, error) {
+	n := opts.Size
+	if n < 6 || n > MaxGenerateSize {
+		return nil, fmt.Errorf("map size must be between 6 and %d, got %d", MaxGenerateSize, n)
+	}
+	if opts.Barriers < 0 || opts.Forests < 0 || opts.Barriers+opts.Forests > 0.9 {
+		return nil, fmt.Errorf("invalid densities: barriers %v, forests %v", opts.Barriers, opts.Forests)
+	}
+	g := &generator{n: n, rnd: rand.New(rand.NewSource(opts.Seed))}
+	g.cells = make([][]int32, n)
+	for x := range g.cells {
+		g.cells[x] = make([]int32, n)
+		for y := range g.cells[x] {
+			if x == 0 || y == 0 || x == n-1 || y == n-1 {
+				g.cells[x][y] = Barrier
+			}
+		}
+	}
+
+	inner := (n - 2) * (n - 2)
+	g.fill(Barrier, int(opts.Barriers*float64(inner)), 4, true)
+	g.fill(Forest, int(opts.Forests*float64(inner)), 6, false)
+	g.connect()
+
+	if err := Validate(g.cells); err != nil {
+		// not expected, the generator keeps every rule by construction
+		return nil, err
+	}
+	return g.cells, nil
+}
+
+type generator struct {
+	n     int
+	cells [][]int32
+	rnd   *rand.Rand
+}
+
+// reserved reports whether a cell must stay empty: the flag cell, the spawn
+// cells and their neighbours.
+func (g *generator) reserved(x, y int) bool {
+	c := g.n / 2
+	if x == c && y == c {
+		return true
+	}
+	// keep the two spawn corners open
+	return (x <= 3 && y <= 3) || (x >= g.n-4 && y >= g.n-4)
+}
+
+// set sets a cell and its rotation, it returns how many cells changed.
+func (g *generator) set(x, y int, v int32) int {
+	rx, ry := g.n-1-x, g.n-1-y
+	if x <= 0 || y <= 0 || x >= g.n-1 || y >= g.n-1 || g.reserved(x, y) || g.reserved(rx, ry) || g.cells[x][y] != Empty {
+		return 0
+	}
+	g.cells[x][y] = v
+	if rx == x && ry == y {
+		return 1
+	}
+	g.cells[rx][ry] = v
+	return 2
+}
+
+// fill places about count cells of kind v in groups of up to size cells,
+// straight walls when straight is set and random patches otherwise.
+func (g *generator) fill(v int32, count, size int, straight bool) {
+	dirs := [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+	for placed, tries := 0, 0; placed < count && tries < 100*g.n*g.n; tries++ {
+		x, y := 1+g.rnd.Intn(g.n-2), 1+g.rnd.Intn(g.n-2)
+		d := dirs[g.rnd.Intn(len(dirs))]
+		length := 1 + g.rnd.Intn(size)
+		for i := 0; i < length && placed < count; i++ {
+			placed += g.set(x, y, v)
+			if !straight {
+				d = dirs[g.rnd.Intn(len(dirs))]
+			}
+			x, y = x+d[0], y+d[1]
+		}
+	}
+}
+
+// connect clears the fewest barriers needed to join the spawn corners, and
+// their rotations to keep the map symmetric.
+func (g *generator) connect() {
+	n := g.n
+	if Reachable(g.cells, 1, 1, n-2, n-2) {
+		return
+	}
+	// 0-1 BFS where crossing a barrier costs 1
+	const inf = 1 << 30
+	dist := make([]int, n*n)
+	prev := make([]int, n*n)
+	for i := range dist {
+		dist[i] = inf
+		prev[i] = -1
+	}
+	start, goal := 1*n+1, (n-2)*n+n-2
+	dist[start] = 0
+	deque := []int{start}
+	for len(deque) > 0 {
+		cur := deque[0]
+		deque = deque[1:]
+		x, y := cur/n, cur%n
+		for _, d := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+			nx, ny := x+d[0], y+d[1]
+			if nx <= 0 || ny <= 0 || nx >= n-1 || ny >= n-1 {
+				continue
+			}
+			w := 0
+			if g.cells[nx][ny] == Barrier {
+				w = 1
+			}
+			next := nx*n + ny
+			if dist[cur]+w < dist[next] {
+				dist[next] = dist[cur] + w
+				prev[next] = cur
+				if w == 0 {
+					deque = append([]int{next}, deque...)
+				} else {
+					deque = append(deque, next)
+				}
+			}
+		}
+	}
+	for cur := goal; cur != -1; cur = prev[cur] {
+		x, y := cur/n, cur%n
+		if g.cells[x][y] == Barrier {
+			g.cells[x][y] = Empty
+			g.cells[n-1-x][n-1-y] = Empty
+		}
+	}
+}
+
+// Write writes cells in the map file format.
+func Write(w io.Writer, cells [][]int32) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "size: %d\n", len(cells))
+	for _, row := range cells {
+		for j, c := range row {
+			if j > 0 {
+				bw.WriteByte(' ')
+			}
+			bw.WriteString(strconv.Itoa(int(c)))
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,63 @@
+package gamemap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	for _, size := range []int{6, 7, 15, 19, 21, MaxGenerateSize} {
+		for seed := int64(1); seed <= 20; seed++ {
+			opts := GenerateOptions{Size: size, Barriers: 0.3, Forests: 0.2, Seed: seed}
+			cells, err := Generate(opts)
+			if err != nil {
+				t.Fatalf("size %d seed %d: %v", size, seed, err)
+			}
+			if len(cells) != size {
+				t.Fatalf("size %d seed %d: the map is %d cells a side", size, seed, len(cells))
+			}
+			for x := range cells {
+				for y := range cells[x] {
+					if cells[x][y] != cells[size-1-x][size-1-y] {
+						t.Fatalf("size %d seed %d: %d,%d is not its rotation", size, seed, x, y)
+					}
+					border := x == 0 || y == 0 || x == size-1 || y == size-1
+					if border && cells[x][y] != Barrier {
+						t.Fatalf("size %d seed %d: border cell %d,%d is open", size, seed, x, y)
+					}
+				}
+			}
+			if c := size / 2; cells[c][c] != Empty {
+				t.Errorf("size %d seed %d: the flag cell is %d", size, seed, cells[c][c])
+			}
+			if !Reachable(cells, 1, 1, size-2, size-2) {
+				t.Errorf("size %d seed %d: the spawn corners are apart", size, seed)
+			}
+			again, _ := Generate(opts)
+			if !reflect.DeepEqual(cells, again) {
+				t.Errorf("size %d seed %d: the seed gave another map", size, seed)
+			}
+		}
+	}
+}
+
+func TestGenerateOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts GenerateOptions
+		ok   bool
+	}{
+		{"smallest", GenerateOptions{Size: 6}, true},
+		{"too small", GenerateOptions{Size: 5}, false},
+		{"largest", GenerateOptions{Size: 29}, true},
+		{"too large", GenerateOptions{Size: 30}, false},
+		{"largest a bot holds", GenerateOptions{Size: MaxSize}, false},
+		{"negative density", GenerateOptions{Size: 19, Barriers: -0.1}, false},
+		{"too dense", GenerateOptions{Size: 19, Barriers: 0.5, Forests: 0.5}, false},
+	}
+	for _, test := range tests {
+		if _, err := Generate(test.opts); (err == nil) != test.ok {
+			t.Errorf("%s: Generate gave %v, want ok %v", test.name, err, test.ok)
+		}
+	}
+}
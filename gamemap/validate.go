@@ -0,0 +1,116 @@
+package gamemap
+
+import "fmt"
+
+// SpawnTanks is the largest number of tanks per player in the competition,
+// the spawn cells of that many tanks must be free.
+const SpawnTanks = 4
+
+// spawnCells are the cells where the referee puts the first player's tanks,
+// the second player's tanks spawn at the rotationally symmetric cells.
+var spawnCells = [][2]int{{1, 1}, {1, 2}, {2, 1}, {2, 2}, {1, 3}}
+
+// Validate checks that a map follows the competition rules: it is square, its
+// border is barriers, the flag cell at the center is free, it is symmetric
+// under a 180 degree rotation about the center, the spawn cells are free and
+// the two spawn corners are connected. Problems are reported as an ErrorList
+// with 1-based row and cell index.
+func Validate(cells [][]int32) error {
+	if errs := validate(cells); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validate(cells [][]int32) ErrorList {
+	errs := ErrorList{}
+	at := func(x, y int, format string, args ...interface{}) {
+		errs = append(errs, &Error{x + 1, y + 1, fmt.Sprintf(format, args...)})
+	}
+
+	n := len(cells)
+	if n < 6 {
+		return ErrorList{{Msg: fmt.Sprintf("map size %d is too small, the spawn corners overlap", n)}}
+	}
+	for x, row := range cells {
+		if len(row) != n {
+			at(x, 0, "row has %d cells, the map is not %dx%d", len(row), n, n)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	center := n / 2
+	for x := 0; x < n; x++ {
+		for y := 0; y < n; y++ {
+			c := cells[x][y]
+			switch {
+			case c < Empty || c > Flag:
+				at(x, y, "invalid cell %d", c)
+			case (x == 0 || y == 0 || x == n-1 || y == n-1) && c != Barrier:
+				at(x, y, "border cell is %d, expect a barrier", c)
+			case c == Flag && (x != center || y != center):
+				at(x, y, "flag must be at the center (%d, %d)", center, center)
+			}
+		}
+	}
+	if cells[center][center] == Barrier {
+		at(center, center, "flag cell at the center is a barrier")
+	}
+
+	for x := 0; x < n; x++ {
+		for y := 0; y < n; y++ {
+			rx, ry := n-1-x, n-1-y
+			// report each pair once, the flag cell has no counterpart
+			if x*n+y >= rx*n+ry || cells[x][y] == Flag || cells[rx][ry] == Flag {
+				continue
+			}
+			if cells[x][y] != cells[rx][ry] {
+				at(x, y, "cell is %d but its rotation at row %d, cell %d is %d, the map is not symmetric", cells[x][y], rx+1, ry+1, cells[rx][ry])
+			}
+		}
+	}
+
+	for _, s := range spawnCells[:SpawnTanks] {
+		if cells[s[0]][s[1]] == Barrier {
+			at(s[0], s[1], "spawn cell is a barrier")
+		}
+		if cells[n-1-s[0]][n-1-s[1]] == Barrier {
+			at(n-1-s[0], n-1-s[1], "spawn cell is a barrier")
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if !Reachable(cells, 1, 1, n-2, n-2) {
+		at(n-2, n-2, "spawn corner cannot be reached from the other one")
+	}
+	return errs
+}
+
+// Reachable reports whether a tank can drive from (fromX, fromY) to
+// (toX, toY) without crossing a barrier.
+func Reachable(cells [][]int32, fromX, fromY, toX, toY int) bool {
+	n := len(cells)
+	seen := make([]bool, n*n)
+	queue := [][2]int{{fromX, fromY}}
+	seen[fromX*n+fromY] = true
+	for len(queue) > 0 {
+		x, y := queue[0][0], queue[0][1]
+		queue = queue[1:]
+		if x == toX && y == toY {
+			return true
+		}
+		for _, d := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+			nx, ny := x+d[0], y+d[1]
+			if nx < 0 || ny < 0 || nx >= n || ny >= n || seen[nx*n+ny] || cells[nx][ny] == Barrier {
+				continue
+			}
+			seen[nx*n+ny] = true
+			queue = append(queue, [2]int{nx, ny})
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package gamemap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	// set returns the valid map of 6 with the cells given set, each a row,
+	// a cell and a value.
+	set := func(changes ...[3]int) [][]int32 {
+		cells, err := Parse(strings.NewReader(validMap))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range changes {
+			cells[c[0]][c[1]] = int32(c[2])
+		}
+		return cells
+	}
+	tests := []struct {
+		name  string
+		cells [][]int32
+		// row and cell are where the first error is, 1-based, 0 if there is
+		// none, and msg is in its message.
+		row, cell int
+		msg       string
+	}{
+		{"valid", set(), 0, 0, ""},
+		{"forest and flag", set([3]int{1, 3, Forest}, [3]int{4, 2, Forest}, [3]int{3, 3, Flag}), 0, 0, ""},
+		{"too small", [][]int32{{1, 1}, {1, 1}}, 0, 0, "too small"},
+		{"not square", append(set()[:5], []int32{1, 1, 1, 1, 1}), 6, 1, "not 6x6"},
+		{"invalid cell", set([3]int{1, 3, 5}, [3]int{4, 2, 5}), 2, 4, "invalid cell"},
+		{"open border", set([3]int{0, 2, Empty}, [3]int{5, 3, Empty}), 1, 3, "border"},
+		{"flag off center", set([3]int{1, 3, Flag}), 2, 4, "flag must be at the center"},
+		{"flag cell barrier", set([3]int{3, 3, Barrier}), 4, 4, "flag cell"},
+		{"not symmetric", set([3]int{1, 3, Forest}), 2, 4, "not symmetric"},
+		{"spawn cell barrier", set([3]int{1, 2, Barrier}, [3]int{4, 3, Barrier}), 2, 3, "spawn cell"},
+		{"corners apart", set([3]int{1, 3, Barrier}, [3]int{2, 3, Barrier}, [3]int{3, 2, Barrier}, [3]int{4, 2, Barrier}), 5, 5, "cannot be reached"},
+	}
+	for _, test := range tests {
+		err := Validate(test.cells)
+		if test.msg == "" {
+			if err != nil {
+				t.Errorf("%s: %v", test.name, err)
+			}
+			continue
+		}
+		errs, ok := err.(ErrorList)
+		if !ok || len(errs) == 0 {
+			t.Errorf("%s: Validate gave %v, want an ErrorList", test.name, err)
+			continue
+		}
+		e := errs[0]
+		if e.Line != test.row || e.Column != test.cell || !strings.Contains(e.Msg, test.msg) {
+			t.Errorf("%s: Validate gave %v, want %q at row %d, cell %d", test.name, err, test.msg, test.row, test.cell)
+		}
+	}
+}
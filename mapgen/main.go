@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gamemap"
+	"log"
+	"os"
+	"time"
+)
+
+func main() {
+	size := flag.Int("size", 19, "width and height of the map, from 6 to 29")
+	barriers := flag.Float64("barriers", 0.15, "share of the inner cells which are barriers")
+	forests := flag.Float64("forests", 0.1, "share of the inner cells which are forest")
+	seed := flag.Int64("seed", 0, "random seed, 0 picks one from the clock")
+	out := flag.String("o", "", "output file, default stdout")
+	flag.Parse()
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	cells, err := gamemap.Generate(gamemap.GenerateOptions{
+		Size:     *size,
+		Barriers: *barriers,
+		Forests:  *forests,
+		Seed:     *seed,
+	})
+	if err != nil {
+		log.Fatalln("Error:", err)
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatalln("Error:", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	// the seed goes in a comment so the map can be generated again
+	fmt.Fprintf(w, "// mapgen -size %d -barriers %v -forests %v -seed %d\n", *size, *barriers, *forests, *seed)
+	if err := gamemap.Write(w, cells); err != nil {
+		log.Fatalln("Error:", err)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"engine"
+	"flag"
+	"fmt"
+	"gamemap"
+	"log"
+	"os"
+	"replay"
+	"strconv"
+	"time"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+const usage = `Usage: referee [-replay file] <map> <tanks> <tankSpeed> <shellSpeed> <tankHP> <tankScore> <flagScore> <maxRound> <roundTimeoutInMs> <playerA> <playerB>
+
+Same arguments as ele.me.hackathon.tank.GameEngine, e.g.
+  referee game_engine/maps/secondweekmap.txt 4 1 2 1 1 1 100 2000 localhost:8080 localhost:8081
+
+-replay records every round of the match, see package replay.`
+
+func main() {
+	replayPath := flag.String("replay", "", "record the match to this file")
+	flag.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() != 11 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	args := flag.Args()
+
+	var nums [8]int
+	for i := range nums {
+		n, err := strconv.Atoi(args[i+1])
+		if err != nil {
+			log.Fatalln("Error:", err)
+		}
+		nums[i] = n
+	}
+	opts := engine.Options{
+		NoOfTanks:      nums[0],
+		TankSpeed:      nums[1],
+		ShellSpeed:     nums[2],
+		TankHP:         nums[3],
+		TankScore:      nums[4],
+		FlagScore:      nums[5],
+		MaxRound:       nums[6],
+		RoundTimeoutMs: nums[7],
+	}
+	fmt.Printf("Parameters parsed. %+v\n", opts)
+
+	gameMap, err := gamemap.LoadFile(args[0])
+	if gameMap == nil {
+		log.Fatalln("Error:", err)
+	}
+	// like the Java engine, play on maps which break the competition rules
+	if err != nil {
+		fmt.Println("Warning: map does not follow the competition rules:")
+		fmt.Println(err)
+	}
+
+	timeout := time.Duration(opts.RoundTimeoutMs) * time.Millisecond
+	clientB := make(chan player.PlayerService)
+	go func() { clientB <- engine.Connect(args[10], timeout) }()
+	a := engine.Contestant{Name: args[9], Service: engine.Connect(args[9], timeout)}
+	b := engine.Contestant{Name: args[10], Service: <-clientB}
+
+	match, err := engine.NewMatch(gameMap, opts, a, b)
+	if err != nil {
+		log.Fatalln("Error:", err)
+	}
+	if *replayPath != "" {
+		rec, err := replay.Create(*replayPath)
+		if err != nil {
+			log.Fatalln("Error:", err)
+		}
+		defer rec.Close()
+		match.Recorder = rec
+	}
+	res := match.Run()
+	out, _ := json.MarshalIndent(res, "", "  ")
+	fmt.Println("Game result:", string(out))
+}
@@ -0,0 +1,254 @@
+// Package replay records matches as JSON Lines and reads them back.
+//
+// Every line of a replay is a Record. The map, the parameters and the tanks
+// of each player come first, then for each round the state and the orders
+// of the players. A bot records the state it was sent and its own orders,
+// the referee records the whole board and the orders of both players.
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+// Record types.
+const (
+	TypeMap    = "map"
+	TypeArgs   = "args"
+	TypeTanks  = "tanks"
+	TypeState  = "state"
+	TypeOrders = "orders"
+)
+
+// maxRounds bounds the rounds of a replay whose parameters do not give
+// MaxRound, so that a corrupt round number cannot grow Rounds without
+// bound.
+const maxRounds = 10000
+
+// Record is one line of a replay.
+type Record struct {
+	Type   string            `json:"type"`
+	Round  int               `json:"round"`
+	Player string            `json:"player,omitempty"`
+	Map    [][]int32         `json:"map,omitempty"`
+	Args   *player.Args_     `json:"args,omitempty"`
+	Tanks  []int32           `json:"tanks,omitempty"`
+	State  *player.GameState `json:"state,omitempty"`
+	Orders []*player.Order   `json:"orders,omitempty"`
+}
+
+// Recorder writes a replay. All methods are safe on a nil Recorder, they do
+// nothing, so a bot can call them whether recording is enabled or not.
+type Recorder struct {
+	mu     sync.Mutex
+	w      *bufio.Writer
+	enc    *json.Encoder
+	closer io.Closer
+}
+
+// NewRecorder returns a recorder writing to w.
+func NewRecorder(w io.Writer) *Recorder {
+	bw := bufio.NewWriter(w)
+	r := &Recorder{w: bw, enc: json.NewEncoder(bw)}
+	if c, ok := w.(io.Closer); ok {
+		r.closer = c
+	}
+	return r
+}
+
+// Create returns a recorder writing to a new file at path.
+func Create(path string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewRecorder(f), nil
+}
+
+// Write writes a record. Records are flushed at once so that a replay is
+// complete up to the last round even if the process dies.
+func (r *Recorder) Write(rec *Record) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(rec); err != nil {
+		return err
+	}
+	return r.w.Flush()
+}
+
+// Map records the map.
+func (r *Recorder) Map(gameMap [][]int32) error {
+	return r.Write(&Record{Type: TypeMap, Map: gameMap})
+}
+
+// Args records the match parameters.
+func (r *Recorder) Args(args *player.Args_) error {
+	return r.Write(&Record{Type: TypeArgs, Args: args})
+}
+
+// Tanks records the tanks assigned to a player.
+func (r *Recorder) Tanks(name string, tanks []int32) error {
+	return r.Write(&Record{Type: TypeTanks, Player: name, Tanks: tanks})
+}
+
+// State records the state of a round.
+func (r *Recorder) State(round int, state *player.GameState) error {
+	return r.Write(&Record{Type: TypeState, Round: round, State: state})
+}
+
+// Orders records the orders a player gave in a round.
+func (r *Recorder) Orders(round int, name string, orders []*player.Order) error {
+	return r.Write(&Record{Type: TypeOrders, Round: round, Player: name, Orders: orders})
+}
+
+// Close flushes the replay and closes the underlying writer if it is a
+// Closer.
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.w.Flush(); err != nil {
+		return err
+	}
+	if r.closer != nil {
+		return r.closer.Close()
+	}
+	return nil
+}
+
+// Replay is a recorded match.
+type Replay struct {
+	Map  [][]int32
+	Args *player.Args_
+	// Players are the player names in the order their tanks were recorded.
+	Players []string
+	// Tanks are the tanks of each player.
+	Tanks map[string][]int32
+	// Rounds are indexed by round number.
+	Rounds []*Round
+}
+
+// Round is the state of a round and the orders given in it.
+type Round struct {
+	State  *player.GameState
+	Orders map[string][]*player.Order
+}
+
+// Read reads a replay. A truncated last line, left by a process which died
+// while writing, is ignored.
+func Read(r io.Reader) (*Replay, error) {
+	rep := &Replay{Tanks: map[string][]int32{}}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	var pending error
+	for scanner.Scan() {
+		line++
+		if pending != nil {
+			return nil, pending
+		}
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		rec := &Record{}
+		if err := json.Unmarshal(scanner.Bytes(), rec); err != nil {
+			// only an error if it is not the last line
+			pending = fmt.Errorf("line %d: %v", line, err)
+			continue
+		}
+		if err := rep.add(rec); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rep, nil
+}
+
+// ReadFile reads the replay at path.
+func ReadFile(path string) (*Replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+func (rep *Replay) add(rec *Record) error {
+	switch rec.Type {
+	case TypeMap:
+		rep.Map = rec.Map
+	case TypeArgs:
+		rep.Args = rec.Args
+	case TypeTanks:
+		if _, ok := rep.Tanks[rec.Player]; !ok {
+			rep.Players = append(rep.Players, rec.Player)
+		}
+		rep.Tanks[rec.Player] = rec.Tanks
+	case TypeState:
+		r, err := rep.round(rec.Round)
+		if err != nil {
+			return err
+		}
+		r.State = rec.State
+	case TypeOrders:
+		r, err := rep.round(rec.Round)
+		if err != nil {
+			return err
+		}
+		r.Orders[rec.Player] = rec.Orders
+	default:
+		return fmt.Errorf("unknown record type %q", rec.Type)
+	}
+	return nil
+}
+
+// round returns round n, adding the rounds up to it. The referee records
+// the state after the last round too, so n goes from 0 to MaxRound of the
+// parameters, or maxRounds before they are read.
+func (rep *Replay) round(n int) (*Round, error) {
+	max := maxRounds
+	if rep.Args != nil && rep.Args.MaxRound > 0 {
+		max = int(rep.Args.MaxRound)
+	}
+	if n < 0 || n > max {
+		return nil, fmt.Errorf("round %d out of 0 to %d", n, max)
+	}
+	for len(rep.Rounds) <= n {
+		rep.Rounds = append(rep.Rounds, &Round{Orders: map[string][]*player.Order{}})
+	}
+	return rep.Rounds[n], nil
+}
+
+// Round returns the state and the orders of round n.
+func (rep *Replay) Round(n int) (*Round, error) {
+	if n < 0 || n >= len(rep.Rounds) || rep.Rounds[n].State == nil {
+		return nil, fmt.Errorf("round %d not recorded, the replay has %d rounds", n, len(rep.Rounds))
+	}
+	return rep.Rounds[n], nil
+}
+
+// Owner returns the player owning the tank, or "" if the tank is unknown.
+func (rep *Replay) Owner(tank int32) string {
+	for _, name := range rep.Players {
+		for _, id := range rep.Tanks[name] {
+			if id == tank {
+				return name
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,45 @@
+package replay
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+func TestReadRounds(t *testing.T) {
+	tests := []struct {
+		name string
+		args *player.Args_
+		// round is the round of the state recorded.
+		round int
+		ok    bool
+	}{
+		{"first", &player.Args_{MaxRound: 10}, 0, true},
+		{"after the last", &player.Args_{MaxRound: 10}, 10, true},
+		{"negative", &player.Args_{MaxRound: 10}, -1, false},
+		{"past the last", &player.Args_{MaxRound: 10}, 11, false},
+		{"no parameters", nil, maxRounds, true},
+		{"huge", nil, maxRounds + 1, false},
+	}
+	for _, test := range tests {
+		buf := &bytes.Buffer{}
+		r := NewRecorder(buf)
+		if test.args != nil {
+			r.Args(test.args)
+		}
+		r.State(test.round, &player.GameState{})
+		r.Orders(test.round, "a", []*player.Order{})
+		rep, err := Read(buf)
+		if ok := err == nil; ok != test.ok {
+			t.Errorf("%s: Read gave %v, want ok %v", test.name, err, test.ok)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if _, err := rep.Round(test.round); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"astar"
+	"flag"
+	"fmt"
+	"html/template"
+	"log"
+	"os"
+	"path/filepath"
+	"replay"
+	"strings"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+// page is what the HTML template animates. Positions are [x, y] pairs, x is
+// the row and y the column like everywhere else.
+type page struct {
+	Title   string      `json:"title"`
+	Map     [][]int32   `json:"map"`
+	Players []string    `json:"players"`
+	Rounds  []pageRound `json:"rounds"`
+}
+
+type pageRound struct {
+	Round  int         `json:"round"`
+	Tanks  []pageTank  `json:"tanks"`
+	Shells []pageShell `json:"shells"`
+	Flag   []int32     `json:"flag"`
+	Flags  []int32     `json:"flags"`
+	// Lost lists the tanks of each side that are not on the board.
+	Lost [][]int32 `json:"lost"`
+}
+
+type pageTank struct {
+	ID    int32   `json:"id"`
+	Side  int     `json:"side"`
+	Pos   []int32 `json:"pos"`
+	Dir   string  `json:"dir"`
+	HP    int32   `json:"hp"`
+	Order string  `json:"order"`
+	// FlagPath is the shortest path from the tank to the flag cell, by the
+	// A* of the bots with their costs. It is not the route the bot of the
+	// tank planned, the replays do not record those.
+	FlagPath [][]int32 `json:"flagPath"`
+}
+
+type pageShell struct {
+	ID  int32   `json:"id"`
+	Pos []int32 `json:"pos"`
+	Dir string  `json:"dir"`
+}
+
+func main() {
+	out := flag.String("o", "", "output file, default the replay path with .html")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: replayhtml [-o file] <replay>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	path := flag.Arg(0)
+	if *out == "" {
+		*out = strings.TrimSuffix(path, filepath.Ext(path)) + ".html"
+	}
+
+	rep, err := replay.ReadFile(path)
+	if err != nil {
+		log.Fatalln("Error:", err)
+	}
+	p := newPage(rep)
+	p.Title = filepath.Base(path)
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatalln("Error:", err)
+	}
+	defer f.Close()
+	if err := pageTemplate.Execute(f, p); err != nil {
+		log.Fatalln("Error:", err)
+	}
+	fmt.Println("Written to", *out)
+}
+
+var pageTemplate = template.Must(template.New("page").Parse(pageHTML))
+
+func newPage(rep *replay.Replay) *page {
+	p := &page{Map: rep.Map, Players: rep.Players}
+
+	// the grid has the size of the board, cells of short rows are barriers
+	size := len(rep.Map)
+	grid := astar.NewGrid(size, size)
+	for x := 0; x < size; x++ {
+		for y := 0; y < size; y++ {
+			cell := int32(1)
+			if y < len(rep.Map[x]) {
+				cell = rep.Map[x][y]
+			}
+			grid.SetCost(x, y, astar.DefaultCosts(x, y, cell))
+		}
+	}
+	center := size / 2
+
+	for n, r := range rep.Rounds {
+		if r.State == nil {
+			continue
+		}
+		pr := pageRound{
+			Round:  n,
+			Tanks:  []pageTank{},
+			Shells: []pageShell{},
+			Flags:  []int32{r.State.YourFlagNo, r.State.EnemyFlagNo},
+			Lost:   make([][]int32, len(rep.Players)),
+		}
+		if r.State.FlagPos != nil {
+			pr.Flag = []int32{r.State.FlagPos.X, r.State.FlagPos.Y}
+		}
+		orders := map[int32]*player.Order{}
+		for _, list := range r.Orders {
+			for _, o := range list {
+				orders[o.TankId] = o
+			}
+		}
+		seen := map[int32]bool{}
+		for _, t := range r.State.Tanks {
+			seen[t.ID] = true
+			pt := pageTank{
+				ID:       t.ID,
+				Side:     side(rep, t.ID),
+				Pos:      []int32{t.Pos.X, t.Pos.Y},
+				Dir:      t.Dir.String(),
+				HP:       t.Hp,
+				Order:    describeOrder(orders[t.ID]),
+				FlagPath: [][]int32{},
+			}
+			if x, y := int(t.Pos.X), int(t.Pos.Y); x >= 0 && x < size && y >= 0 && y < size {
+				if steps, _, found := grid.Path(x, y, center, center); found {
+					// the path goes from the flag back to the tank
+					for i := len(steps) - 1; i >= 0; i-- {
+						x, y := grid.XY(steps[i])
+						pt.FlagPath = append(pt.FlagPath, []int32{int32(x), int32(y)})
+					}
+				}
+			}
+			pr.Tanks = append(pr.Tanks, pt)
+		}
+		for i, name := range rep.Players {
+			pr.Lost[i] = []int32{}
+			for _, id := range rep.Tanks[name] {
+				if !seen[id] {
+					pr.Lost[i] = append(pr.Lost[i], id)
+				}
+			}
+		}
+		for _, s := range r.State.Shells {
+			pr.Shells = append(pr.Shells, pageShell{ID: s.ID, Pos: []int32{s.Pos.X, s.Pos.Y}, Dir: s.Dir.String()})
+		}
+		p.Rounds = append(p.Rounds, pr)
+	}
+	return p
+}
+
+// side returns the index of the player owning the tank, -1 if unknown.
+func side(rep *replay.Replay, id int32) int {
+	owner := rep.Owner(id)
+	for i, name := range rep.Players {
+		if name == owner {
+			return i
+		}
+	}
+	return -1
+}
+
+func describeOrder(o *player.Order) string {
+	if o == nil {
+		return ""
+	}
+	if o.Order == "move" {
+		return o.Order
+	}
+	return o.Order + " " + o.Dir.String()
+}
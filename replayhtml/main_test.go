@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"replay"
+	"testing"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+// boardMap returns a board of size cells a side walled by barriers.
+func boardMap(size int) [][]int32 {
+	m := make([][]int32, size)
+	for x := range m {
+		m[x] = make([]int32, size)
+		for y := range m[x] {
+			if x == 0 || y == 0 || x == size-1 || y == size-1 {
+				m[x][y] = 1
+			}
+		}
+	}
+	return m
+}
+
+// record returns the replay recorded by rec.
+func record(t *testing.T, rec func(r *replay.Recorder)) *replay.Replay {
+	buf := &bytes.Buffer{}
+	r := replay.NewRecorder(buf)
+	rec(r)
+	rep, err := replay.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rep
+}
+
+func tank(id, x, y int32, dir player.Direction) *player.Tank {
+	return &player.Tank{ID: id, Pos: &player.Position{X: x, Y: y}, Dir: dir, Hp: 1}
+}
+
+// checkFlagPath checks that the path goes from the tank to the flag cell in
+// steps of one cell, off the barriers, and has cells cells.
+func checkFlagPath(t *testing.T, m [][]int32, pt pageTank, cells int) {
+	path := pt.FlagPath
+	center := int32(len(m) / 2)
+	if len(path) != cells {
+		t.Errorf("tank %d: the flag path has %d cells, want %d: %v", pt.ID, len(path), cells, path)
+		return
+	}
+	if !reflect.DeepEqual(path[0], pt.Pos) || !reflect.DeepEqual(path[len(path)-1], []int32{center, center}) {
+		t.Errorf("tank %d: the flag path goes from %v to %v, want from %v to the flag", pt.ID, path[0], path[len(path)-1], pt.Pos)
+	}
+	for i, c := range path {
+		if m[c[0]][c[1]] == 1 {
+			t.Errorf("tank %d: the flag path crosses the barrier %v", pt.ID, c)
+		}
+		if i > 0 {
+			if d := abs(c[0]-path[i-1][0]) + abs(c[1]-path[i-1][1]); d != 1 {
+				t.Errorf("tank %d: the flag path jumps from %v to %v", pt.ID, path[i-1], c)
+			}
+		}
+	}
+}
+
+func abs(n int32) int32 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func TestNewPage(t *testing.T) {
+	m := boardMap(7)
+	m[3][2] = 1
+	rep := record(t, func(r *replay.Recorder) {
+		r.Map(m)
+		r.Tanks("a", []int32{1})
+		r.Tanks("b", []int32{2})
+		r.State(0, &player.GameState{
+			Tanks:  []*player.Tank{tank(1, 3, 1, player.Direction_DOWN), tank(2, 5, 5, player.Direction_UP)},
+			Shells: []*player.Shell{{ID: 1, Pos: &player.Position{X: 4, Y: 1}, Dir: player.Direction_DOWN}},
+		})
+		r.Orders(0, "a", []*player.Order{{TankId: 1, Order: "move", Dir: player.Direction_DOWN}})
+		r.Orders(0, "b", []*player.Order{{TankId: 2, Order: "fire", Dir: player.Direction_LEFT}})
+		r.State(1, &player.GameState{
+			Tanks:      []*player.Tank{tank(1, 4, 1, player.Direction_DOWN)},
+			FlagPos:    &player.Position{X: 3, Y: 3},
+			YourFlagNo: 1,
+		})
+		// round 2 is missing
+		r.State(3, &player.GameState{Tanks: []*player.Tank{tank(1, 4, 2, player.Direction_RIGHT)}})
+	})
+	p := newPage(rep)
+
+	if !reflect.DeepEqual(p.Players, []string{"a", "b"}) || len(p.Map) != 7 {
+		t.Fatalf("players %v on a board of %d", p.Players, len(p.Map))
+	}
+	rounds := []int{}
+	for _, r := range p.Rounds {
+		rounds = append(rounds, r.Round)
+	}
+	if !reflect.DeepEqual(rounds, []int{0, 1, 3}) {
+		t.Fatalf("rounds %v, want [0 1 3]", rounds)
+	}
+
+	first := p.Rounds[0]
+	if len(first.Tanks) != 2 || len(first.Shells) != 1 || first.Flag != nil {
+		t.Fatalf("round 0: %d tanks, %d shells, flag %v", len(first.Tanks), len(first.Shells), first.Flag)
+	}
+	for i, want := range []pageTank{
+		{ID: 1, Side: 0, Pos: []int32{3, 1}, Dir: "DOWN", HP: 1, Order: "move"},
+		{ID: 2, Side: 1, Pos: []int32{5, 5}, Dir: "UP", HP: 1, Order: "fire LEFT"},
+	} {
+		got := first.Tanks[i]
+		got.FlagPath = nil
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("round 0: tank %+v, want %+v", got, want)
+		}
+	}
+	// the barrier on 3, 2 makes tank 1 go round it
+	checkFlagPath(t, m, first.Tanks[0], 5)
+	checkFlagPath(t, m, first.Tanks[1], 5)
+	if !reflect.DeepEqual(first.Lost, [][]int32{{}, {}}) {
+		t.Errorf("round 0: lost %v, want none", first.Lost)
+	}
+
+	second := p.Rounds[1]
+	if !reflect.DeepEqual(second.Lost, [][]int32{{}, {2}}) {
+		t.Errorf("round 1: lost %v, want tank 2", second.Lost)
+	}
+	if !reflect.DeepEqual(second.Flag, []int32{3, 3}) || !reflect.DeepEqual(second.Flags, []int32{1, 0}) {
+		t.Errorf("round 1: flag %v and flags %v, want [3 3] and [1 0]", second.Flag, second.Flags)
+	}
+	if second.Tanks[0].Order != "" {
+		t.Errorf("round 1: tank 1 has the order %q, none was recorded", second.Tanks[0].Order)
+	}
+}
+
+// TestNewPageLarge draws the flag paths on a board larger than the 50 cells
+// of the bots' A* worlds.
+func TestNewPageLarge(t *testing.T) {
+	m := boardMap(60)
+	rep := record(t, func(r *replay.Recorder) {
+		r.Map(m)
+		r.Tanks("a", []int32{1})
+		r.Tanks("b", []int32{2})
+		r.State(0, &player.GameState{Tanks: []*player.Tank{tank(1, 1, 1, player.Direction_DOWN), tank(2, 58, 58, player.Direction_UP)}})
+	})
+	p := newPage(rep)
+	checkFlagPath(t, m, p.Rounds[0].Tanks[0], 59)
+	checkFlagPath(t, m, p.Rounds[0].Tanks[1], 57)
+}
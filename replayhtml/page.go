@@ -0,0 +1,173 @@
+package main
+
+// pageHTML draws the board as SVG and animates it from the embedded rounds.
+// It must not load anything from the network, the file is shared as is.
+const pageHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: monospace; margin: 16px; background: #fafafa; }
+#main { display: flex; align-items: flex-start; }
+#board { border: 1px solid #999; background: #fff; }
+#side { margin-left: 16px; min-width: 260px; }
+#controls { margin: 8px 0; }
+#controls input[type=range] { width: 400px; vertical-align: middle; }
+.player { margin-bottom: 12px; }
+.player0 { color: #c62828; }
+.player1 { color: #1565c0; }
+table { border-collapse: collapse; }
+td { padding: 0 6px; }
+</style>
+</head>
+<body>
+<h3>{{.Title}}</h3>
+<div id="controls">
+<button id="prev">&lt;</button>
+<button id="play">play</button>
+<button id="next">&gt;</button>
+<input id="scrub" type="range" min="0" value="0">
+<span id="round"></span>
+<label><input id="showShells" type="checkbox" checked> shells</label>
+<label><input id="showPaths" type="checkbox"> paths to the flag</label>
+</div>
+<div id="main">
+<svg id="board" xmlns="http://www.w3.org/2000/svg"></svg>
+<div id="side"></div>
+</div>
+<script>
+var data = {{.}};
+var cell = Math.max(8, Math.min(24, Math.floor(720 / data.map.length)));
+var colors = ["#c62828", "#1565c0", "#555"];
+var delta = {UP: [-1, 0], DOWN: [1, 0], LEFT: [0, -1], RIGHT: [0, 1]};
+var ns = "http://www.w3.org/2000/svg";
+var board = document.getElementById("board");
+var scrub = document.getElementById("scrub");
+var current = 0, timer = null;
+
+function el(name, attrs, parent) {
+	var e = document.createElementNS(ns, name);
+	for (var k in attrs) e.setAttribute(k, attrs[k]);
+	parent.appendChild(e);
+	return e;
+}
+
+// x is the row and y the column, so x goes down the page
+function cx(pos) { return pos[1] * cell + cell / 2; }
+function cy(pos) { return pos[0] * cell + cell / 2; }
+
+function drawTerrain() {
+	var n = data.map.length;
+	board.setAttribute("width", n * cell);
+	board.setAttribute("height", n * cell);
+	var g = el("g", {}, board);
+	for (var x = 0; x < n; x++) {
+		for (var y = 0; y < data.map[x].length; y++) {
+			var c = data.map[x][y];
+			if (c == 1 || c == 2) {
+				el("rect", {x: y * cell, y: x * cell, width: cell, height: cell, fill: c == 1 ? "#777" : "#a5d6a7"}, g);
+			}
+		}
+	}
+}
+
+var layer = null;
+function draw() {
+	var r = data.rounds[current];
+	if (layer) board.removeChild(layer);
+	layer = el("g", {}, board);
+	document.getElementById("round").textContent = "round " + r.round + " / " + data.rounds[data.rounds.length - 1].round;
+	scrub.value = current;
+
+	if (r.flag) {
+		el("text", {x: cx(r.flag), y: cy(r.flag) + cell / 3, "text-anchor": "middle", "font-size": cell, fill: "#f9a825"}, layer).textContent = "⚑";
+	}
+	if (document.getElementById("showPaths").checked) {
+		r.tanks.forEach(function(t) {
+			if (t.flagPath.length < 2) return;
+			var pts = t.flagPath.map(function(p) { return cx(p) + "," + cy(p); }).join(" ");
+			el("polyline", {points: pts, fill: "none", stroke: colors[t.side < 0 ? 2 : t.side], "stroke-width": 2, "stroke-dasharray": "4 3", opacity: 0.6}, layer);
+		});
+	}
+	if (document.getElementById("showShells").checked) {
+		r.shells.forEach(function(s) {
+			var d = delta[s.dir] || [0, 0];
+			el("line", {x1: cx(s.pos), y1: cy(s.pos), x2: cx(s.pos) - d[1] * cell / 2, y2: cy(s.pos) - d[0] * cell / 2, stroke: "#000", "stroke-width": 1}, layer);
+			el("circle", {cx: cx(s.pos), cy: cy(s.pos), r: cell / 6, fill: "#000"}, layer);
+		});
+	}
+	r.tanks.forEach(function(t) {
+		var d = delta[t.dir] || [0, 0];
+		var color = colors[t.side < 0 ? 2 : t.side];
+		el("circle", {cx: cx(t.pos), cy: cy(t.pos), r: cell * 0.4, fill: color}, layer);
+		el("line", {x1: cx(t.pos), y1: cy(t.pos), x2: cx(t.pos) + d[1] * cell * 0.6, y2: cy(t.pos) + d[0] * cell * 0.6, stroke: color, "stroke-width": 3}, layer);
+		el("text", {x: cx(t.pos), y: cy(t.pos) + cell / 5, "text-anchor": "middle", "font-size": cell / 2, fill: "#fff"}, layer).textContent = t.id;
+	});
+
+	var side = document.getElementById("side");
+	side.innerHTML = "";
+	data.players.forEach(function(name, i) {
+		var div = document.createElement("div");
+		div.className = "player player" + i;
+		var h = document.createElement("b");
+		h.textContent = name + "  flags: " + r.flags[i];
+		div.appendChild(h);
+		var table = document.createElement("table");
+		r.tanks.filter(function(t) { return t.side == i; }).forEach(function(t) {
+			var tr = table.insertRow();
+			[t.id, "(" + t.pos[0] + "," + t.pos[1] + ")", t.dir, "hp " + t.hp, t.order].forEach(function(v) {
+				tr.insertCell().textContent = v;
+			});
+		});
+		r.lost[i].forEach(function(id) {
+			var tr = table.insertRow();
+			tr.insertCell().textContent = id;
+			tr.insertCell().textContent = "-";
+		});
+		div.appendChild(table);
+		side.appendChild(div);
+	});
+}
+
+function go(n) {
+	current = Math.max(0, Math.min(data.rounds.length - 1, n));
+	draw();
+}
+
+function toggle() {
+	if (timer) {
+		clearInterval(timer);
+		timer = null;
+		document.getElementById("play").textContent = "play";
+		return;
+	}
+	if (current == data.rounds.length - 1) go(0);
+	document.getElementById("play").textContent = "pause";
+	timer = setInterval(function() {
+		if (current == data.rounds.length - 1) {
+			toggle();
+			return;
+		}
+		go(current + 1);
+	}, 300);
+}
+
+scrub.max = data.rounds.length - 1;
+scrub.oninput = function() { go(parseInt(scrub.value, 10)); };
+document.getElementById("prev").onclick = function() { go(current - 1); };
+document.getElementById("next").onclick = function() { go(current + 1); };
+document.getElementById("play").onclick = toggle;
+document.getElementById("showShells").onchange = draw;
+document.getElementById("showPaths").onchange = draw;
+document.onkeydown = function(e) {
+	if (e.key == "ArrowLeft") go(current - 1);
+	if (e.key == "ArrowRight") go(current + 1);
+	if (e.key == " ") { toggle(); e.preventDefault(); }
+};
+drawTerrain();
+if (data.rounds.length > 0) go(0);
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"replay"
+	"strconv"
+	"strings"
+)
+
+const help = `Enter or n: next round   b: previous round   <number>: jump to round
+f: first round           l: last round       q: quit`
+
+func main() {
+	round := flag.Int("round", 0, "round to start at")
+	once := flag.Bool("print", false, "print the round and exit")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: replayview [-round N] [-print] <replay>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	rep, err := replay.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatalln("Error:", err)
+	}
+	if len(rep.Rounds) == 0 {
+		log.Fatalln("Error: no rounds in", flag.Arg(0))
+	}
+	if *once {
+		out, err := render(rep, *round)
+		if err != nil {
+			log.Fatalln("Error:", err)
+		}
+		fmt.Print(out)
+		return
+	}
+
+	last := len(rep.Rounds) - 1
+	n := *round
+	msg := ""
+	input := bufio.NewScanner(os.Stdin)
+	for {
+		// clear the screen and draw from the top left corner
+		fmt.Print("\033[H\033[2J")
+		out, err := render(rep, n)
+		if err != nil {
+			out = err.Error() + "\n"
+		}
+		fmt.Print(out)
+		fmt.Println()
+		fmt.Println(help)
+		if msg != "" {
+			fmt.Println(msg)
+			msg = ""
+		}
+		fmt.Print("> ")
+		if !input.Scan() {
+			return
+		}
+		var quit bool
+		n, quit, msg = command(strings.TrimSpace(input.Text()), n, last)
+		if quit {
+			return
+		}
+	}
+}
+
+// command returns the round to show after the command cmd, typed in round n
+// of a replay whose last round is last. quit is true for q, and msg tells
+// why an unknown command leaves the round as it is.
+func command(cmd string, n, last int) (next int, quit bool, msg string) {
+	switch cmd {
+	case "", "n":
+		if n < last {
+			n++
+		}
+	case "b", "p":
+		if n > 0 {
+			n--
+		}
+	case "f":
+		n = 0
+	case "l":
+		n = last
+	case "q":
+		return n, true, ""
+	default:
+		i, err := strconv.Atoi(cmd)
+		if err != nil || i < 0 || i > last {
+			return n, false, fmt.Sprintf("Unknown command %q, rounds are 0 to %d", cmd, last)
+		}
+		n = i
+	}
+	return n, false, ""
+}
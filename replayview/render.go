@@ -0,0 +1,112 @@
+package main
+
+import (
+	"astar"
+	"bytes"
+	"fmt"
+	"replay"
+	"strconv"
+	"strings"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+// arrows show the direction of tanks and shells.
+var arrows = map[player.Direction]string{
+	player.Direction_UP:    "↑",
+	player.Direction_DOWN:  "↓",
+	player.Direction_LEFT:  "←",
+	player.Direction_RIGHT: "→",
+}
+
+// render draws round n of the replay: the board, where every cell is two
+// characters wide, and below it a pane with the tanks and orders of each
+// side.
+//
+// Terrain uses the runes of astar.RenderPath. A tank is its ID followed by
+// its direction, a shell is * followed by its direction and F is the flag.
+func render(rep *replay.Replay, n int) (string, error) {
+	r, err := rep.Round(n)
+	if err != nil {
+		return "", err
+	}
+	state := r.State
+
+	cells := make([][]string, len(rep.Map))
+	for x, row := range rep.Map {
+		cells[x] = make([]string, len(row))
+		for y, c := range row {
+			switch c {
+			case 1:
+				cells[x][y] = string(astar.KindRunes[astar.KindBlocker]) + " "
+			case 2:
+				cells[x][y] = string(astar.KindRunes[astar.KindGrass]) + " "
+			default:
+				cells[x][y] = string(astar.KindRunes[astar.KindPlain]) + " "
+			}
+		}
+	}
+	set := func(pos *player.Position, s string) {
+		if pos != nil && int(pos.X) < len(cells) && pos.X >= 0 && pos.Y >= 0 && int(pos.Y) < len(cells[pos.X]) {
+			cells[pos.X][pos.Y] = s
+		}
+	}
+	if state.FlagPos != nil {
+		set(state.FlagPos, "F ")
+	}
+	for _, s := range state.Shells {
+		set(s.Pos, "*"+arrows[s.Dir])
+	}
+	// tanks last, they hide the shells and the flag under them
+	for _, t := range state.Tanks {
+		set(t.Pos, strconv.FormatInt(int64(t.ID), 36)+arrows[t.Dir])
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Round %d/%d\n", n, len(rep.Rounds)-1)
+	for _, row := range cells {
+		for _, c := range row {
+			buf.WriteString(c)
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\n")
+
+	for i, name := range rep.Players {
+		flags := state.EnemyFlagNo
+		if i == 0 {
+			flags = state.YourFlagNo
+		}
+		fmt.Fprintf(&buf, "%s  flags: %d\n", name, flags)
+		orders := map[int32]*player.Order{}
+		for _, o := range r.Orders[name] {
+			orders[o.TankId] = o
+		}
+		for _, id := range rep.Tanks[name] {
+			line := fmt.Sprintf("  tank %-2d %-20s %s", id, describeTank(state, id), describeOrder(orders[id]))
+			buf.WriteString(strings.TrimRight(line, " ") + "\n")
+		}
+	}
+	return buf.String(), nil
+}
+
+// describeTank returns the position, direction and HP of a tank, a tank
+// missing from the state is destroyed or, in a bot's replay, hidden.
+func describeTank(state *player.GameState, id int32) string {
+	for _, t := range state.Tanks {
+		if t.ID == id {
+			return fmt.Sprintf("(%d,%d) %s hp %d", t.Pos.X, t.Pos.Y, arrows[t.Dir], t.Hp)
+		}
+	}
+	return "-"
+}
+
+func describeOrder(o *player.Order) string {
+	if o == nil {
+		return ""
+	}
+	if o.Order == "move" {
+		return o.Order
+	}
+	return o.Order + " " + o.Dir.String()
+}
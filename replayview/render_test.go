@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"replay"
+	"strings"
+	"testing"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+func TestRender(t *testing.T) {
+	buf := &bytes.Buffer{}
+	r := replay.NewRecorder(buf)
+	r.Map([][]int32{
+		{1, 1, 1, 1, 1},
+		{1, 0, 0, 0, 1},
+		{1, 0, 0, 2, 1},
+		{1, 0, 0, 0, 1},
+		{1, 1, 1, 1, 1},
+	})
+	r.Tanks("a", []int32{1, 2})
+	r.Tanks("b", []int32{3})
+	r.State(0, &player.GameState{
+		Tanks: []*player.Tank{
+			{ID: 1, Pos: &player.Position{X: 1, Y: 1}, Dir: player.Direction_DOWN, Hp: 1},
+			{ID: 3, Pos: &player.Position{X: 3, Y: 3}, Dir: player.Direction_UP, Hp: 2},
+		},
+		Shells:  []*player.Shell{{ID: 1, Pos: &player.Position{X: 2, Y: 1}, Dir: player.Direction_DOWN}},
+		FlagPos: &player.Position{X: 2, Y: 2},
+	})
+	r.Orders(0, "a", []*player.Order{{TankId: 1, Order: "fire", Dir: player.Direction_DOWN}})
+	r.Orders(0, "b", []*player.Order{{TankId: 3, Order: "move", Dir: player.Direction_UP}})
+	r.State(1, &player.GameState{})
+	rep, err := replay.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := render(rep, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// tank 2 is not on the board, the tanks of both sides have orders
+	want := strings.Join([]string{
+		"Round 0/1",
+		"X X X X X ",
+		"X 1↓. . X ",
+		"X *↓F ~ X ",
+		"X . . 3↑X ",
+		"X X X X X ",
+		"",
+		"a  flags: 0",
+		"  tank 1  (1,1) ↓ hp 1         fire DOWN",
+		"  tank 2  -",
+		"b  flags: 0",
+		"  tank 3  (3,3) ↑ hp 2         move",
+		"",
+	}, "\n")
+	if got != want {
+		t.Errorf("round 0 renders as\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := render(rep, 2); err == nil {
+		t.Error("rendering a round not recorded gave no error")
+	}
+}
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		cmd     string
+		n, last int
+		next    int
+		quit    bool
+		// unknown is whether the command is unknown.
+		unknown bool
+	}{
+		{"", 3, 10, 4, false, false},
+		{"n", 3, 10, 4, false, false},
+		{"n", 10, 10, 10, false, false},
+		{"b", 3, 10, 2, false, false},
+		{"p", 3, 10, 2, false, false},
+		{"b", 0, 10, 0, false, false},
+		{"f", 3, 10, 0, false, false},
+		{"l", 3, 10, 10, false, false},
+		{"7", 3, 10, 7, false, false},
+		{"0", 3, 10, 0, false, false},
+		{"11", 3, 10, 3, false, true},
+		{"-1", 3, 10, 3, false, true},
+		{"x", 3, 10, 3, false, true},
+		{"q", 3, 10, 3, true, false},
+	}
+	for _, test := range tests {
+		next, quit, msg := command(test.cmd, test.n, test.last)
+		if next != test.next || quit != test.quit || (msg != "") != test.unknown {
+			t.Errorf("command %q in round %d of %d: round %d, quit %v, message %q, want round %d, quit %v, unknown %v",
+				test.cmd, test.n, test.last, next, quit, msg, test.next, test.quit, test.unknown)
+		}
+	}
+}
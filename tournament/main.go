@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bot"
+	"engine"
+	"flag"
+	"fmt"
+	"gamemap"
+	"log"
+	"os"
+	"path/filepath"
+	"replay"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eleme/purchaseMeiTuan/player"
+)
+
+const usage = `Usage: tournament [flags] <player> <player> [<player>...]
+
+A player is the host:port of a bot, "bot:<strategy>" for a strategy of the
+bot package played in-process, or "idle" for an in-process player whose
+tanks never act. The tournament is played on the first -pairmaps of the maps
+in name order, five as in the competition: every pair of players meets on
+each of them twice, once from each spawn corner. A win is worth 3 points, a
+draw 1 and a loss 0.`
+
+// standing is the record of one player in the tournament.
+type standing struct {
+	Name                   string
+	Won, Drawn, Lost       int
+	Points                 int
+	ScoreFor, ScoreAgainst int
+}
+
+func (s *standing) played() int {
+	return s.Won + s.Drawn + s.Lost
+}
+
+func main() {
+	maps := flag.String("maps", "game_engine/maps/*.txt", "glob of the map files")
+	pairMaps := flag.Int("pairmaps", 5, "maps played, the first in name order, 0 for all of them")
+	replays := flag.String("replays", "replays", "directory for the replays, empty to not record them")
+	opts := engine.Options{}
+	flag.IntVar(&opts.NoOfTanks, "tanks", 4, "tanks per player")
+	flag.IntVar(&opts.TankSpeed, "tankspeed", 1, "tank speed")
+	flag.IntVar(&opts.ShellSpeed, "shellspeed", 2, "shell speed")
+	flag.IntVar(&opts.TankHP, "hp", 1, "tank HP")
+	flag.IntVar(&opts.TankScore, "tankscore", 1, "score of a surviving tank")
+	flag.IntVar(&opts.FlagScore, "flagscore", 1, "score of a flag")
+	flag.IntVar(&opts.MaxRound, "rounds", 100, "rounds of a game")
+	flag.IntVar(&opts.RoundTimeoutMs, "timeout", 2000, "timeout of a round in milliseconds")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, usage)
+		fmt.Fprintln(os.Stderr)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	players := flag.Args()
+	if len(players) < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	standings := map[string]*standing{}
+	for _, p := range players {
+		if standings[p] != nil {
+			log.Fatalln("Error: player", p, "is listed twice")
+		}
+		if strings.HasPrefix(p, "bot:") {
+			if _, err := bot.New(strings.TrimPrefix(p, "bot:")); err != nil {
+				log.Fatalln("Error:", err)
+			}
+		}
+		standings[p] = &standing{Name: p}
+	}
+
+	files, err := filepath.Glob(*maps)
+	if err != nil {
+		log.Fatalln("Error:", err)
+	}
+	if len(files) == 0 {
+		log.Fatalln("Error: no map matches", *maps)
+	}
+	sort.Strings(files)
+	if *pairMaps > 0 && *pairMaps < len(files) {
+		files = files[:*pairMaps]
+	}
+	gameMaps := make([][][]int32, len(files))
+	for i, f := range files {
+		if gameMaps[i], err = gamemap.LoadFile(f); err != nil {
+			log.Fatalf("Error: %s: %v", f, err)
+		}
+	}
+	if *replays != "" {
+		if err := os.MkdirAll(*replays, 0755); err != nil {
+			log.Fatalln("Error:", err)
+		}
+	}
+
+	games := schedule(players, len(files))
+	for n, g := range games {
+		f := files[g.m]
+		var rec *replay.Recorder
+		if *replays != "" {
+			name := fmt.Sprintf("%03d-%s-%s-vs-%s.jsonl", n+1, strings.TrimSuffix(filepath.Base(f), filepath.Ext(f)), fileName(g.a), fileName(g.b))
+			if rec, err = replay.Create(filepath.Join(*replays, name)); err != nil {
+				log.Fatalln("Error:", err)
+			}
+		}
+		res, err := play(gameMaps[g.m], opts, g.a, g.b, rec)
+		rec.Close()
+		if err != nil {
+			log.Fatalln("Error:", err)
+		}
+		detail := res.State
+		if detail == "" {
+			detail = res.Reason
+		}
+		fmt.Printf("[%d/%d] %s  %s vs %s: %s %s (%s)\n", n+1, len(games), filepath.Base(f), g.a, g.b, res.Result, res.Win, detail)
+		score(standings[g.a], standings[g.b], res)
+	}
+
+	fmt.Println()
+	printStandings(standings)
+}
+
+// game is one game of the tournament, a plays from the first spawn corner
+// on the map of index m.
+type game struct {
+	a, b string
+	m    int
+}
+
+// schedule returns the games of a tournament on maps maps: every pair of
+// players meets on every map twice, once from each spawn corner.
+func schedule(players []string, maps int) []game {
+	games := []game{}
+	for i := range players {
+		for j := i + 1; j < len(players); j++ {
+			for _, pair := range [][2]string{{players[i], players[j]}, {players[j], players[i]}} {
+				for m := 0; m < maps; m++ {
+					games = append(games, game{pair[0], pair[1], m})
+				}
+			}
+		}
+	}
+	return games
+}
+
+// play plays one game, a from the first spawn corner.
+func play(gameMap [][]int32, opts engine.Options, a, b string, rec *replay.Recorder) (*engine.Result, error) {
+	timeout := time.Duration(opts.RoundTimeoutMs) * time.Millisecond
+	ca := engine.Contestant{Name: a, Service: newPlayer(a, timeout)}
+	cb := engine.Contestant{Name: b, Service: newPlayer(b, timeout)}
+	defer engine.Disconnect(ca.Service)
+	defer engine.Disconnect(cb.Service)
+
+	match, err := engine.NewMatch(gameMap, opts, ca, cb)
+	if err != nil {
+		return nil, err
+	}
+	match.Recorder = rec
+	return match.Run(), nil
+}
+
+// newPlayer returns the PlayerService of a player argument.
+func newPlayer(name string, timeout time.Duration) player.PlayerService {
+	if name == "idle" {
+		return engine.IdlePlayer{}
+	}
+	if strings.HasPrefix(name, "bot:") {
+		// main checked that the strategy exists
+		p, _ := bot.NewPlayerService(bot.Config{Strategy: strings.TrimPrefix(name, "bot:"), Name: name})
+		return p
+	}
+	return engine.Connect(name, timeout)
+}
+
+// score applies the competition scoring: 3 points for a win, 1 for a draw.
+func score(a, b *standing, res *engine.Result) {
+	a.ScoreFor += res.Scores[a.Name]
+	a.ScoreAgainst += res.Scores[b.Name]
+	b.ScoreFor += res.Scores[b.Name]
+	b.ScoreAgainst += res.Scores[a.Name]
+	switch {
+	case res.Result == "draw":
+		a.Drawn++
+		b.Drawn++
+		a.Points++
+		b.Points++
+	case res.Win == a.Name:
+		a.Won++
+		b.Lost++
+		a.Points += 3
+	default:
+		b.Won++
+		a.Lost++
+		b.Points += 3
+	}
+}
+
+// printStandings prints the players by points, then wins, then score
+// difference.
+func printStandings(standings map[string]*standing) {
+	list := []*standing{}
+	for _, s := range standings {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		if a.Won != b.Won {
+			return a.Won > b.Won
+		}
+		if a.ScoreFor-a.ScoreAgainst != b.ScoreFor-b.ScoreAgainst {
+			return a.ScoreFor-a.ScoreAgainst > b.ScoreFor-b.ScoreAgainst
+		}
+		return a.Name < b.Name
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tPlayer\tPlayed\tWon\tDrawn\tLost\tScore\tPoints\t")
+	for i, s := range list {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%d\t%d\t%d:%d\t%d\t\n", i+1, s.Name, s.played(), s.Won, s.Drawn, s.Lost, s.ScoreFor, s.ScoreAgainst, s.Points)
+	}
+	w.Flush()
+}
+
+// fileName makes a player name safe to use in a file name.
+func fileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.' {
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSchedule(t *testing.T) {
+	players := []string{"a", "b", "c", "d"}
+	const maps = 5
+	games := schedule(players, maps)
+	if want := len(players) * (len(players) - 1) * maps; len(games) != want {
+		t.Fatalf("%d games, want %d", len(games), want)
+	}
+	// the maps each ordered pair plays on
+	played := map[[2]string][]int{}
+	for _, g := range games {
+		if g.a == g.b {
+			t.Fatalf("%s plays itself", g.a)
+		}
+		played[[2]string{g.a, g.b}] = append(played[[2]string{g.a, g.b}], g.m)
+	}
+	want := []int{0, 1, 2, 3, 4}
+	for _, a := range players {
+		for _, b := range players {
+			if a == b {
+				continue
+			}
+			if got := played[[2]string{a, b}]; !reflect.DeepEqual(got, want) {
+				t.Errorf("%s vs %s plays maps %v, want %v", a, b, got, want)
+			}
+		}
+	}
+}